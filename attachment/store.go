@@ -0,0 +1,179 @@
+package attachment
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+	"github.com/kirychukyurii/notificator/server"
+)
+
+const defaultDir = "attachments"
+
+// cleanupInterval limits how often expired files are removed.
+const cleanupInterval = time.Hour
+
+// ErrTooLarge is returned when the file exceeds the maximum size of the store.
+var ErrTooLarge = errors.New("attachment is too large")
+
+// Store keeps files attached to alerts in the directory and serves them by
+// unguessable names, so notifiers can link them.
+type Store struct {
+	log *wlog.Logger
+	cfg *config.Attachments
+	dir string
+	url string
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// NewStore returns nil if the store is not configured.
+func NewStore(log *wlog.Logger, cfg *config.Attachments, sessionDir string, srv *server.Server) (*Store, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(sessionDir, defaultDir)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		log: log,
+		cfg: cfg,
+		dir: dir,
+		url: srv.PublicURL() + "/attachments/",
+	}
+
+	srv.HandleFunc("/attachments/{name}", s.handleFile)
+	s.cleanup(time.Now())
+
+	return s, nil
+}
+
+// MaxSize returns the maximum size of a file.
+func (s *Store) MaxSize() int64 {
+	return s.cfg.MaxSize
+}
+
+// Save writes the file of the attachment and sets its path and URL, the file
+// is removed if write fails or exceeds the maximum size.
+func (s *Store) Save(a *model.Attachment, write func(w io.Writer) error) error {
+	if a.Size > s.cfg.MaxSize {
+		return ErrTooLarge
+	}
+
+	s.cleanup(time.Now())
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	name := hex.EncodeToString(b) + filepath.Ext(filepath.Base(a.Name))
+	path := filepath.Join(s.dir, name)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := &limitedWriter{w: f, n: s.cfg.MaxSize}
+	err = write(w)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(path)
+		if w.exceeded {
+			return ErrTooLarge
+		}
+
+		return fmt.Errorf("write attachment: %w", err)
+	}
+
+	a.Path = path
+	a.URL = s.url + name
+
+	return nil
+}
+
+// handleFile serves stored files, names are random, so they are not listed.
+func (s *Store) handleFile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	http.ServeFile(w, r, filepath.Join(s.dir, name))
+}
+
+// cleanup removes files older than the retention, at most once per interval.
+func (s *Store) cleanup(now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastCleanup) < cleanupInterval || s.cfg.Retention <= 0 {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.lastCleanup = now
+	s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		s.log.Warn("list attachments", wlog.Err(err))
+
+		return
+	}
+
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() || now.Sub(info.ModTime()) < s.cfg.Retention {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(s.dir, e.Name())); err != nil {
+			s.log.Warn("remove expired attachment", wlog.Err(err), wlog.String("name", e.Name()))
+		}
+	}
+}
+
+// limitedWriter fails writes beyond n bytes.
+type limitedWriter struct {
+	w        io.Writer
+	n        int64
+	exceeded bool
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.n {
+		l.exceeded = true
+
+		return 0, ErrTooLarge
+	}
+
+	n, err := l.w.Write(p)
+	l.n -= int64(n)
+
+	return n, err
+}
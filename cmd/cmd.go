@@ -56,7 +56,7 @@ func command() *cobra.Command {
 	}
 
 	flagSet(c.PersistentFlags())
-	c.AddCommand(listenCommand(cfg, log))
+	c.AddCommand(listenCommand(cfg, log), deadLetterCommand(cfg, log), silenceCommand(cfg))
 
 	return c
 }
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/notifier"
+)
+
+func deadLetterCommand(cfg *config.Config, log *wlog.Logger) *cobra.Command {
+	c := &cobra.Command{
+		Use:          "dead-letter",
+		Short:        "Inspect and replay undelivered alerts",
+		SilenceUsage: true,
+	}
+
+	c.AddCommand(deadLetterListCommand(cfg), deadLetterReplayCommand(cfg, log))
+
+	return c
+}
+
+func deadLetterListCommand(cfg *config.Config) *cobra.Command {
+	var verbose bool
+	c := &cobra.Command{
+		Use:          "list",
+		Short:        "List undelivered alert groups",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dl, err := openDeadLetter(cfg)
+			if err != nil {
+				return err
+			}
+
+			entries, err := dl.List()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tCREATED\tNOTIFIER\tTECHNICAL\tALERTS\tATTEMPTS\tERROR")
+			for _, e := range entries {
+				technical := ""
+				if e.Technical != nil {
+					technical = e.Technical.Name
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n", e.ID, e.CreatedAt.Format(time.DateTime), e.Notifier,
+					technical, len(e.Alerts), e.Attempts, e.Error)
+
+				if verbose {
+					for _, a := range e.Alerts {
+						fmt.Fprintf(w, "\t\t\t\t%s\t\t\n", a.String())
+					}
+				}
+			}
+
+			return w.Flush()
+		},
+	}
+
+	c.Flags().BoolVarP(&verbose, "verbose", "v", false, "print alerts of each group")
+
+	return c
+}
+
+func deadLetterReplayCommand(cfg *config.Config, log *wlog.Logger) *cobra.Command {
+	var all bool
+	c := &cobra.Command{
+		Use:          "replay [id...]",
+		Short:        "Send undelivered alert groups to their notifiers again",
+		SilenceUsage: true,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if !all && len(args) == 0 {
+				return fmt.Errorf("specify entries to replay or use --all")
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dl, err := openDeadLetter(cfg)
+			if err != nil {
+				return err
+			}
+
+			log.SetConsoleLevel(cfg.Logger.Level)
+			nrs, err := notifier.NewNotifiers(log, cfg.Notifiers)
+			if err != nil {
+				return err
+			}
+
+			byName := make(map[string]notifier.Notifier, len(nrs))
+			for _, n := range nrs {
+				byName[n.String()] = n
+			}
+
+			var entries []*notifier.DeadLetterEntry
+			if all {
+				if entries, err = dl.List(); err != nil {
+					return err
+				}
+			} else {
+				for _, id := range args {
+					e, err := dl.Get(id)
+					if err != nil {
+						return fmt.Errorf("read entry %s: %v", id, err)
+					}
+
+					entries = append(entries, e)
+				}
+			}
+
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			var failed int
+			for _, e := range entries {
+				n, ok := byName[e.Notifier]
+				if !ok {
+					log.Error("notifier not configured, skip entry", wlog.String("id", e.ID), wlog.String("notifier", e.Notifier))
+					failed++
+
+					continue
+				}
+
+				if _, err := n.Notify(ctx, e.Technical, e.Alerts...); err != nil {
+					log.Error("replay entry", wlog.Err(err), wlog.String("id", e.ID), wlog.String("notifier", e.Notifier))
+					failed++
+
+					continue
+				}
+
+				if err := dl.Delete(e.ID); err != nil {
+					return err
+				}
+
+				log.Info("entry replayed", wlog.String("id", e.ID), wlog.String("notifier", e.Notifier))
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d entries not replayed", failed, len(entries))
+			}
+
+			return nil
+		},
+	}
+
+	c.Flags().BoolVar(&all, "all", false, "replay all entries")
+
+	return c
+}
+
+func openDeadLetter(cfg *config.Config) (*notifier.DeadLetter, error) {
+	if err := cfg.Load(configPath); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(cfg.SessionsDir); err != nil {
+		return nil, fmt.Errorf("sessions dir: %v", err)
+	}
+
+	return notifier.NewDeadLetter(cfg.SessionsDir)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/kirychukyurii/notificator/listener"
 	"github.com/kirychukyurii/notificator/manager"
 	"github.com/kirychukyurii/notificator/notifier"
+	"github.com/kirychukyurii/notificator/schedule"
 	"github.com/kirychukyurii/notificator/server"
 )
 
@@ -74,17 +76,25 @@ func listenCommand(cfg *config.Config, log *wlog.Logger) *cobra.Command {
 	return c
 }
 
+// handoffCheckInterval is how often the schedule is checked for handoffs.
+const handoffCheckInterval = time.Minute
+
 type App struct {
 	cfg *config.Config
 	log *wlog.Logger
 
 	scheduler *listener.Scheduler
+	schedule  *schedule.Schedule
 	queue     *notifier.Queue
 	srv       *server.Server
 
 	mgr       *manager.Bot
 	listeners []listener.Listener
 
+	// shift is the current listening window, saved on every change of the technical on duty.
+	shiftMu sync.Mutex
+	shift   *schedule.Shift
+
 	// Closed once the App has finished starting
 	startedCh            chan struct{}
 	initializedListeners chan struct{}
@@ -100,6 +110,11 @@ func New(cfg *config.Config, log *wlog.Logger) (*App, error) {
 	}
 
 	scheduler := listener.NewScheduler(log, timezone)
+	rotations, err := schedule.New(cfg, timezone)
+	if err != nil {
+		return nil, err
+	}
+
 	notifiers, err := notifier.NewNotifiers(log, cfg.Notifiers)
 	if err != nil {
 		return nil, err
@@ -110,16 +125,21 @@ func New(cfg *config.Config, log *wlog.Logger) (*App, error) {
 		return nil, err
 	}
 
-	q := notifier.NewQueue(log, cfg.GroupWait, notifiers, mgr)
 	srv, err := server.New(log, cfg.HttpServer)
 	if err != nil {
 		return nil, err
 	}
 
+	q, err := notifier.NewQueue(log, cfg, notifiers, mgr, srv)
+	if err != nil {
+		return nil, err
+	}
+
 	app := &App{
 		cfg:                  cfg,
 		log:                  log,
 		scheduler:            scheduler,
+		schedule:             rotations,
 		queue:                q,
 		srv:                  srv,
 		mgr:                  mgr,
@@ -136,14 +156,6 @@ func New(cfg *config.Config, log *wlog.Logger) (*App, error) {
 	return app, nil
 }
 
-// TODO: Panic recovery
-// 	1. App down
-// 	2. App start
-// 	3. Check if time between start/stop intervals
-// 	4. If yes - read onduty from file, skip next run
-// 	5. Start listeners
-// 	6. If no - start as regular
-
 func (a *App) Run(ctx context.Context) error {
 	// Notify anyone who might be listening that the App has finished starting.
 	// This can be used by, e.g., app tests.
@@ -167,40 +179,8 @@ func (a *App) Run(ctx context.Context) error {
 	for i, start := range a.cfg.Start {
 		f := func(job gocron.Job) error {
 			logSchedJob(job)
-			if err := a.mgr.ChooseTechnicals(a.cfg.Technicals); err != nil {
-				return err
-			}
-
-			var onduty *config.Technical
-			select {
-			case phone := <-a.mgr.OnDuty():
-				for _, t := range a.cfg.Technicals {
-					if t.Phone == phone {
-						t.OnDuty = true
-						onduty = t
-					}
-				}
-			}
-
-			// if err := a.mgr.Close(); err != nil {
-			// 	return err
-			// }
-
-			a.queue.WithOnDuty(onduty)
-			wg := &sync.WaitGroup{}
-			for _, l := range a.listeners {
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					if err := l.Listen(ctx); err != nil {
-						a.log.Error("listen events", wlog.Err(err), wlog.String("listener", l.String()))
-					}
-				}()
-			}
-
-			wg.Wait()
 
-			return nil
+			return a.startShift(ctx, nil)
 		}
 
 		_, err := a.scheduler.ScheduleJob(start, fmt.Sprintf("start-%d", i), f)
@@ -212,6 +192,10 @@ func (a *App) Run(ctx context.Context) error {
 	for i, stop := range a.cfg.Stop {
 		f := func(job gocron.Job) error {
 			logSchedJob(job)
+			if err := schedule.RemoveShift(a.cfg.SessionsDir); err != nil {
+				a.log.Error("remove shift", wlog.Err(err))
+			}
+
 			for _, l := range a.listeners {
 				if err := l.Close(); err != nil {
 					return err
@@ -227,6 +211,10 @@ func (a *App) Run(ctx context.Context) error {
 		}
 	}
 
+	if err := a.recoverShift(ctx); err != nil {
+		return err
+	}
+
 	a.log.Info("app started, wait for scheduled jobs")
 
 	// App blocks until it receives a signal to exit
@@ -263,3 +251,191 @@ func (a *App) Cleanup(ctx context.Context) {
 
 	a.log.Info("app cleanup completed")
 }
+
+// recoverShift starts listeners right away if the app was (re)started inside
+// a start/stop window, restoring the technical on duty of the saved shift.
+func (a *App) recoverShift(ctx context.Context) error {
+	now := time.Now()
+	end, active, err := a.scheduler.ActiveWindow(a.cfg.Start, a.cfg.Stop, now)
+	if err != nil {
+		return err
+	}
+
+	shift, err := schedule.LoadShift(a.cfg.SessionsDir)
+	if err != nil {
+		a.log.Error("load shift", wlog.Err(err))
+		shift = nil
+	}
+
+	if !active {
+		if shift != nil {
+			if err := schedule.RemoveShift(a.cfg.SessionsDir); err != nil {
+				a.log.Error("remove shift", wlog.Err(err))
+			}
+		}
+
+		return nil
+	}
+
+	if shift != nil && !shift.Active(now) {
+		shift = nil
+	}
+
+	if shift != nil {
+		if t, ok := a.cfg.Technical(shift.Technical.Phone); ok {
+			shift.Technical = t
+		} else {
+			shift = nil
+		}
+	}
+
+	a.log.Info("app started inside listening window, start listeners", wlog.Any("window_end", end), wlog.Any("restored", shift != nil))
+	go func() {
+		if err := a.startShift(ctx, shift); err != nil {
+			a.log.Error("start shift", wlog.Err(err))
+		}
+	}()
+
+	return nil
+}
+
+// startShift chooses the technical on duty, unless it is restored from the saved
+// shift, and listens until listeners are closed.
+func (a *App) startShift(ctx context.Context, restored *schedule.Shift) error {
+	shiftCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	now := time.Now()
+	end, _, err := a.scheduler.ActiveWindow(a.cfg.Start, a.cfg.Stop, now)
+	if err != nil {
+		return err
+	}
+
+	a.shiftMu.Lock()
+	a.shift = &schedule.Shift{StartedAt: now, EndsAt: end}
+	if restored != nil {
+		a.shift.StartedAt = restored.StartedAt
+	}
+
+	a.shiftMu.Unlock()
+
+	if err := a.chooseOnDuty(shiftCtx, restored); err != nil {
+		return err
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, l := range a.listeners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Listen(ctx); err != nil {
+				a.log.Error("listen events", wlog.Err(err), wlog.String("listener", l.String()))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// chooseOnDuty sets the technical on duty from the schedule and follows its handoffs
+// until the context is done. Without schedule, or when manual override is enabled,
+// the technical is chosen in the manager bot. If the schedule has no technical at
+// the moment, the chosen one fills the gap until the next handoff and alerts are
+// held by the queue meanwhile. Restored technical is used as is without prompting.
+func (a *App) chooseOnDuty(ctx context.Context, restored *schedule.Shift) error {
+	override := &atomic.Bool{}
+	if restored != nil {
+		a.log.Info("technical on duty restored from saved shift", wlog.String("name", restored.Technical.Name))
+		override.Store(restored.Manual)
+		a.setOnDuty(restored.Technical, restored.Manual)
+		if a.schedule.Enabled() {
+			go a.followHandoffs(ctx, override)
+		}
+
+		return nil
+	}
+
+	scheduled, ok := a.schedule.OnCall(time.Now())
+	if ok {
+		a.log.Info("technical on duty from schedule", wlog.String("name", scheduled.Name))
+		a.setOnDuty(scheduled, false)
+	}
+
+	if !ok || a.cfg.Schedule == nil || a.cfg.Schedule.ManualOverride {
+		if err := a.mgr.ChooseTechnicals(ctx, a.cfg.Technicals); err != nil {
+			return err
+		}
+
+		// Technical chosen for the gap in the schedule does not override handoffs.
+		manual := ok
+		go func() {
+			select {
+			case <-ctx.Done():
+			case phone := <-a.mgr.OnDuty():
+				if t, found := a.cfg.Technical(phone); found {
+					override.Store(manual)
+					a.setOnDuty(t, manual)
+				}
+			}
+		}()
+	}
+
+	if a.schedule.Enabled() {
+		go a.followHandoffs(ctx, override)
+	}
+
+	return nil
+}
+
+// followHandoffs updates the technical on duty when the schedule hands off,
+// unless the technical was chosen manually.
+func (a *App) followHandoffs(ctx context.Context, override *atomic.Bool) {
+	ticker := time.NewTicker(handoffCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if override.Load() {
+				continue
+			}
+
+			t, ok := a.schedule.OnCall(now)
+			if !ok {
+				continue
+			}
+
+			if current := a.queue.OnDuty(); current == nil || current.Phone != t.Phone {
+				a.log.Info("schedule handoff", wlog.String("name", t.Name))
+				a.setOnDuty(t, false)
+			}
+		}
+	}
+}
+
+// setOnDuty passes the technical to the queue and saves the shift, so it can be
+// restored after restart.
+func (a *App) setOnDuty(onduty *config.Technical, manual bool) {
+	for _, t := range a.cfg.Technicals {
+		t.OnDuty = t == onduty
+	}
+
+	a.queue.WithOnDuty(onduty)
+
+	a.shiftMu.Lock()
+	defer a.shiftMu.Unlock()
+
+	if a.shift == nil {
+		return
+	}
+
+	a.shift.Technical = onduty
+	a.shift.Manual = manual
+	if err := schedule.SaveShift(a.cfg.SessionsDir, a.shift); err != nil {
+		a.log.Error("save shift", wlog.Err(err))
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/notifier"
+)
+
+func silenceCommand(cfg *config.Config) *cobra.Command {
+	c := &cobra.Command{
+		Use:          "silence",
+		Short:        "Manage silences muting alerts",
+		SilenceUsage: true,
+	}
+
+	c.AddCommand(silenceAddCommand(cfg), silenceListCommand(cfg), silenceExpireCommand(cfg))
+
+	return c
+}
+
+func silenceAddCommand(cfg *config.Config) *cobra.Command {
+	var (
+		duration time.Duration
+		start    string
+		author   string
+		comment  string
+	)
+
+	c := &cobra.Command{
+		Use:          "add <field=value|field=~regex>...",
+		Short:        "Mute alerts matching all matchers",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSilences(cfg)
+			if err != nil {
+				return err
+			}
+
+			silence := &notifier.Silence{
+				StartsAt:  time.Now(),
+				CreatedBy: author,
+				Comment:   comment,
+			}
+
+			if start != "" {
+				loc, err := time.LoadLocation(cfg.Timezone)
+				if err != nil {
+					return fmt.Errorf("load timezone: %v", err)
+				}
+
+				if silence.StartsAt, err = time.ParseInLocation("2006-01-02T15:04", start, loc); err != nil {
+					return fmt.Errorf("start: %v", err)
+				}
+			}
+
+			silence.EndsAt = silence.StartsAt.Add(duration)
+			for _, arg := range args {
+				m, err := notifier.ParseMatcher(arg)
+				if err != nil {
+					return err
+				}
+
+				silence.Matchers = append(silence.Matchers, m)
+			}
+
+			if err := s.Put(silence); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), silence.ID)
+
+			return nil
+		},
+	}
+
+	c.Flags().DurationVarP(&duration, "duration", "d", time.Hour, "how long alerts are muted")
+	c.Flags().StringVar(&start, "start", "", "start of the silence in the configured timezone, formatted as 2006-01-02T15:04, now if empty")
+	c.Flags().StringVar(&author, "author", currentUser(), "author of the silence")
+	c.Flags().StringVar(&comment, "comment", "", "reason of the silence")
+
+	return c
+}
+
+func silenceListCommand(cfg *config.Config) *cobra.Command {
+	var all bool
+	c := &cobra.Command{
+		Use:          "list",
+		Short:        "List active and pending silences",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSilences(cfg)
+			if err != nil {
+				return err
+			}
+
+			silences, err := s.List()
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSTARTS\tENDS\tAUTHOR\tMATCHERS\tCOMMENT")
+			for _, silence := range silences {
+				if !all && !silence.EndsAt.After(now) {
+					continue
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", silence.ID, silence.StartsAt.Format(time.DateTime),
+					silence.EndsAt.Format(time.DateTime), silence.CreatedBy, silence, silence.Comment)
+			}
+
+			return w.Flush()
+		},
+	}
+
+	c.Flags().BoolVar(&all, "all", false, "list expired silences as well")
+
+	return c
+}
+
+func silenceExpireCommand(cfg *config.Config) *cobra.Command {
+	c := &cobra.Command{
+		Use:          "expire <id>...",
+		Short:        "End silences now",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := openSilences(cfg)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range args {
+				if _, err := s.Expire(id); err != nil {
+					return fmt.Errorf("expire silence %s: %v", id, err)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	return c
+}
+
+func openSilences(cfg *config.Config) (*notifier.Silences, error) {
+	if err := cfg.Load(configPath); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(cfg.SessionsDir); err != nil {
+		return nil, fmt.Errorf("sessions dir: %v", err)
+	}
+
+	return notifier.NewSilences(cfg.SessionsDir)
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return strings.TrimSpace(u.Username)
+	}
+
+	return ""
+}
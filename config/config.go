@@ -22,13 +22,152 @@ type Manager struct {
 type Technical struct {
 	Name   string `yaml:"name" json:"name"`
 	Phone  string `yaml:"phone" json:"phone"`
-	OnDuty bool
+	OnDuty bool   `json:"on_duty"`
 }
 
 type HttpServer struct {
 	PublicURL string `yaml:"public_url" json:"public_url"`
 	Bind      string `yaml:"bind_address" json:"bind_address"`
 	Root      string `yaml:"root" json:"root"`
+
+	// APIToken protects management API, e.g. silences, with bearer authorization,
+	// the API is disabled if empty.
+	APIToken string `yaml:"api_token" json:"api_token"`
+}
+
+var DefaultRetry = Retry{
+	MaxAttempts:    5,
+	InitialBackoff: 5 * time.Second,
+	MaxBackoff:     time.Minute,
+}
+
+// Retry configures redelivery of alerts to notifiers that failed with
+// recoverable error.
+type Retry struct {
+	MaxAttempts    int           `yaml:"max_attempts" json:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" json:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" json:"max_backoff"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Retry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultRetry
+	type plain Retry
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var DefaultAck = Ack{
+	DTMF: "1",
+}
+
+// Ack configures acknowledgement of delivered alert groups by the technical.
+type Ack struct {
+	// Timeout after which unacknowledged group is notified again.
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+
+	// DTMF is the digit the technical presses during the call to acknowledge alerts.
+	DTMF string `yaml:"dtmf" json:"dtmf"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Ack) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultAck
+	type plain Ack
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// EscalationPolicy defines who is notified when the alert group is not
+// acknowledged in time: each step is notified after the previous one timed out.
+type EscalationPolicy struct {
+	Name  string            `yaml:"name" json:"name"`
+	Steps []*EscalationStep `yaml:"steps" json:"steps"`
+}
+
+type EscalationStep struct {
+	// Technical is the name of the technical to notify, the one on duty if empty.
+	Technical string `yaml:"technical" json:"technical"`
+
+	// Notifiers lists names of notifiers used on this step, all if empty.
+	Notifiers []string `yaml:"notifiers" json:"notifiers"`
+
+	// Timeout is how long to wait for acknowledgement before escalating to the next step.
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+}
+
+var DefaultDedup = Dedup{
+	Fields: []string{"channel", "chat", "from", "text"},
+	Window: 5 * time.Minute,
+}
+
+// Dedup configures suppression of duplicate alerts.
+type Dedup struct {
+	// Fields are alert fields the fingerprint is calculated from.
+	Fields []string `yaml:"fields" json:"fields"`
+
+	// Window is how long after the first alert its duplicates are suppressed.
+	Window time.Duration `yaml:"window" json:"window"`
+
+	// IgnoreNumbers treats texts differing only in numbers as duplicates.
+	IgnoreNumbers bool `yaml:"ignore_numbers" json:"ignore_numbers"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Dedup) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultDedup
+	type plain Dedup
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SeverityDelivery configures delivery of alerts by their severity. Critical
+// alerts are always notified immediately, bypassing GroupWait.
+type SeverityDelivery struct {
+	// InfoNotifiers are the only notifiers info alerts are sent to, stdout if empty
+	// or notifiers of the root route if stdout is disabled.
+	InfoNotifiers []string `yaml:"info_notifiers" json:"info_notifiers"`
+
+	// DigestInterval collects info alerts into a single digest sent once per
+	// interval, info alerts are grouped as usual if zero.
+	DigestInterval time.Duration `yaml:"digest_interval" json:"digest_interval"`
+}
+
+var DefaultAttachments = Attachments{
+	MaxSize:   20 << 20,
+	Retention: 7 * 24 * time.Hour,
+}
+
+// Attachments configures the store of files attached to alerts.
+type Attachments struct {
+	// Dir is the directory files are stored in, "attachments" in the sessions directory if empty.
+	Dir string `yaml:"dir" json:"dir"`
+
+	// MaxSize is the maximum size of a file in bytes, larger files are only described in alerts.
+	MaxSize int64 `yaml:"max_size" json:"max_size"`
+
+	// Retention is how long files are kept, files are never removed if zero.
+	Retention time.Duration `yaml:"retention" json:"retention"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Attachments) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultAttachments
+	type plain Attachments
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 type Config struct {
@@ -38,11 +177,47 @@ type Config struct {
 
 	Manager    *Manager     `yaml:"manager" json:"manager"`
 	Technicals []*Technical `yaml:"technicals" json:"technicals"`
+	Schedule   *Schedule    `yaml:"schedule" json:"schedule"`
+	Teams      []*Team      `yaml:"teams" json:"teams"`
+
+	SessionsDir string   `yaml:"sessions_dir" json:"sessions_dir"`
+	Start       []string `yaml:"start" json:"start"`
+	Stop        []string `yaml:"stop" json:"stop"`
+	Retry       *Retry   `yaml:"retry" json:"retry"`
+
+	// GroupBy lists alert fields (channel, chat, from) alerts are grouped by.
+	GroupBy []string `yaml:"group_by" json:"group_by"`
+
+	// GroupWait is how long to wait before sending the first notification of a new group.
+	GroupWait time.Duration `yaml:"group_wait" json:"group_wait"`
+
+	// GroupInterval is how long to wait before sending alerts added to a group
+	// which was already notified.
+	GroupInterval time.Duration `yaml:"group_interval" json:"group_interval"`
+
+	// RepeatInterval is how long to wait before re-sending all alerts of a group
+	// which is still active. Zero disables reminders.
+	RepeatInterval time.Duration `yaml:"repeat_interval" json:"repeat_interval"`
 
-	SessionsDir string        `yaml:"sessions_dir" json:"sessions_dir"`
-	Start       []string      `yaml:"start" json:"start"`
-	Stop        []string      `yaml:"stop" json:"stop"`
-	GroupWait   time.Duration `yaml:"group_wait" json:"group_wait"`
+	// Ack enables acknowledgement of delivered alert groups.
+	Ack *Ack `yaml:"ack" json:"ack"`
+
+	// EscalationPolicy is the name of the policy applied to alert groups.
+	EscalationPolicy   string              `yaml:"escalation_policy" json:"escalation_policy"`
+	EscalationPolicies []*EscalationPolicy `yaml:"escalation_policies" json:"escalation_policies"`
+
+	// Dedup enables suppression of duplicate alerts.
+	Dedup *Dedup `yaml:"dedup" json:"dedup"`
+
+	// Severity configures delivery of info and critical alerts.
+	Severity *SeverityDelivery `yaml:"severity" json:"severity"`
+
+	// Attachments enables the store of files attached to alerts by listeners.
+	Attachments *Attachments `yaml:"attachments" json:"attachments"`
+
+	// Route is the root of the routing tree, all alerts are notified with
+	// the settings above if empty.
+	Route *Route `yaml:"route" json:"route"`
 
 	HttpServer *HttpServer `yaml:"http" json:"http"`
 
@@ -50,6 +225,39 @@ type Config struct {
 	Notifiers *notifiers.Notifiers `yaml:"notifiers" json:"notifiers"`
 }
 
+// Technical returns the technical by its name or phone.
+func (c *Config) Technical(name string) (*Technical, bool) {
+	for _, t := range c.Technicals {
+		if t.Name == name || t.Phone == name {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+// Team returns the team by its name.
+func (c *Config) Team(name string) (*Team, bool) {
+	for _, t := range c.Teams {
+		if t.Name == name {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+// Escalation returns the escalation policy by its name.
+func (c *Config) Escalation(name string) (*EscalationPolicy, bool) {
+	for _, p := range c.EscalationPolicies {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return nil, false
+}
+
 func (c *Config) Load(filename string) error {
 	content, err := os.ReadFile(filename)
 	if err != nil {
@@ -60,5 +268,10 @@ func (c *Config) Load(filename string) error {
 		return err
 	}
 
+	if c.Retry == nil {
+		retry := DefaultRetry
+		c.Retry = &retry
+	}
+
 	return nil
 }
@@ -0,0 +1,20 @@
+package listeners
+
+// Filter drops incoming messages before they are pushed to the queue. Keywords
+// are matched case-insensitively as substrings of the message text.
+type Filter struct {
+	// IncludeKeywords and IncludeRegex keep only messages matching any of them.
+	IncludeKeywords []string `yaml:"include_keywords" json:"include_keywords"`
+	IncludeRegex    []string `yaml:"include_regex" json:"include_regex"`
+
+	// ExcludeKeywords and ExcludeRegex drop messages matching any of them.
+	ExcludeKeywords []string `yaml:"exclude_keywords" json:"exclude_keywords"`
+	ExcludeRegex    []string `yaml:"exclude_regex" json:"exclude_regex"`
+
+	// AllowSenders keeps only messages from the senders, DenySenders drops them.
+	AllowSenders []string `yaml:"allow_senders" json:"allow_senders"`
+	DenySenders  []string `yaml:"deny_senders" json:"deny_senders"`
+
+	// AllowChats keeps only messages from the chats.
+	AllowChats []string `yaml:"allow_chats" json:"allow_chats"`
+}
@@ -0,0 +1,15 @@
+package listeners
+
+// Severity assigns severity to incoming messages: the first matching rule wins,
+// messages matching no rule get the default severity.
+type Severity struct {
+	Default string          `yaml:"default" json:"default"`
+	Rules   []*SeverityRule `yaml:"rules" json:"rules"`
+}
+
+// SeverityRule matches message text by case-insensitive keywords or regular expression.
+type SeverityRule struct {
+	Severity string   `yaml:"severity" json:"severity"`
+	Keywords []string `yaml:"keywords" json:"keywords"`
+	Regex    string   `yaml:"regex" json:"regex"`
+}
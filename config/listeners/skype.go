@@ -3,8 +3,10 @@ package listeners
 var DefaultSkypeConfig = SkypeConfig{}
 
 type SkypeConfig struct {
-	Login    string `yaml:"login" json:"login"`
-	Password string `yaml:"password" json:"password"`
+	Login    string    `yaml:"login" json:"login"`
+	Password string    `yaml:"password" json:"password"`
+	Filter   *Filter   `yaml:"filter" json:"filter"`
+	Severity *Severity `yaml:"severity" json:"severity"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
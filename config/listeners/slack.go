@@ -3,8 +3,9 @@ package listeners
 var DefaultSlackConfig = SlackConfig{}
 
 type SlackConfig struct {
-	AppToken string `yaml:"app_token" json:"app_token"`
-	BotToken string `yaml:"bot_token" json:"bot_token"`
+	AppToken string    `yaml:"app_token" json:"app_token"`
+	BotToken string    `yaml:"bot_token" json:"bot_token"`
+	Severity *Severity `yaml:"severity" json:"severity"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -3,12 +3,14 @@ package listeners
 var DefaultTeamsConfig = TeamsConfig{}
 
 type TeamsConfig struct {
-	TenantID      string `yaml:"tenant_id" json:"tenant_id"`
-	ClientID      string `yaml:"client_id" json:"client_id"`
-	ClientSecret  string `yaml:"client_secret" json:"client_secret"`
-	HomeAccountID string `yaml:"home_account_id" json:"home_account_id"`
-	Login         string `yaml:"login" json:"login"`
-	Password      string `yaml:"password" json:"password"`
+	TenantID      string    `yaml:"tenant_id" json:"tenant_id"`
+	ClientID      string    `yaml:"client_id" json:"client_id"`
+	ClientSecret  string    `yaml:"client_secret" json:"client_secret"`
+	HomeAccountID string    `yaml:"home_account_id" json:"home_account_id"`
+	Login         string    `yaml:"login" json:"login"`
+	Password      string    `yaml:"password" json:"password"`
+	Filter        *Filter   `yaml:"filter" json:"filter"`
+	Severity      *Severity `yaml:"severity" json:"severity"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -1,12 +1,54 @@
 package listeners
 
-var DefaultTelegramConfig = TelegramConfig{}
+import "time"
+
+var DefaultTelegramConfig = TelegramConfig{
+	Login:        TelegramLoginTerminal,
+	LoginTimeout: 10 * time.Minute,
+}
+
+// Ways to enter Telegram login code and 2FA password.
+const (
+	// TelegramLoginTerminal reads them from the terminal.
+	TelegramLoginTerminal = "terminal"
+
+	// TelegramLoginRemote requests them via the manager bot and one-time HTTP form,
+	// for running without a terminal, e.g. as a service or in a container.
+	TelegramLoginRemote = "remote"
+
+	// TelegramLoginQR logs in by QR code scanned in the Telegram app, the code is
+	// sent via the manager bot and printed to the terminal. 2FA password is read
+	// from the terminal or requested as in remote login without a terminal.
+	TelegramLoginQR = "qr"
+)
 
 type TelegramConfig struct {
-	Phone            string `yaml:"phone" json:"phone"`
-	AppID            int    `yaml:"app_id" json:"app_id"`
-	AppHash          string `yaml:"app_hash" json:"app_hash"`
-	FillPeersOnStart bool   `yaml:"fill_peers_on_start" json:"fill_peers_on_start" `
+	Phone            string    `yaml:"phone" json:"phone"`
+	AppID            int       `yaml:"app_id" json:"app_id"`
+	AppHash          string    `yaml:"app_hash" json:"app_hash"`
+	FillPeersOnStart bool      `yaml:"fill_peers_on_start" json:"fill_peers_on_start" `
+	Filter           *Filter   `yaml:"filter" json:"filter"`
+	Severity         *Severity `yaml:"severity" json:"severity"`
+
+	// Chats lists IDs or usernames of chats messages are received from, all
+	// chats if empty. Bot API style IDs, e.g. -100123456789, are accepted too.
+	Chats []string `yaml:"chats" json:"chats"`
+
+	// MentionsOnly receives only messages mentioning the account or replying
+	// to it, private messages are always received.
+	MentionsOnly bool `yaml:"mentions_only" json:"mentions_only"`
+
+	// IgnoreMuted skips messages of chats muted by the account.
+	IgnoreMuted bool `yaml:"ignore_muted" json:"ignore_muted"`
+
+	// Login is the way login code and 2FA password are entered, LoginTimeout
+	// limits waiting for each of them in remote login.
+	Login        string        `yaml:"login" json:"login"`
+	LoginTimeout time.Duration `yaml:"login_timeout" json:"login_timeout"`
+
+	// DownloadAttachments saves media of messages to the attachment store,
+	// otherwise media is only described in alerts.
+	DownloadAttachments bool `yaml:"download_attachments" json:"download_attachments"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
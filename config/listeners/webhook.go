@@ -1,17 +1,67 @@
 package listeners
 
-var DefaultWebhookConfig = WebhookConfig{}
+import "time"
 
+var DefaultWebhookConfig = WebhookConfig{
+	Format: WebhookFormatGeneric,
+	ResponseMap: WebhookResponseMap{
+		Required: []string{"message"},
+	},
+}
+
+// Formats of webhook requests.
+const (
+	// WebhookFormatGeneric reads alert from query parameters, headers or body
+	// of the request by the response map.
+	WebhookFormatGeneric = "generic"
+
+	// WebhookFormatAlertmanager reads alerts from Prometheus Alertmanager webhook payload (version 4).
+	WebhookFormatAlertmanager = "alertmanager"
+
+	// WebhookFormatGrafana reads alerts from Grafana unified alerting webhook payload.
+	WebhookFormatGrafana = "grafana"
+
+	// WebhookFormatZabbix reads alert from JSON posted by Zabbix webhook media type.
+	WebhookFormatZabbix = "zabbix"
+
+	// WebhookFormatJira reads alert from Jira issue event.
+	WebhookFormatJira = "jira"
+)
+
+// WebhookResponseMap maps request values to alert fields. Each field is a path
+// expression: query.<name>, header.<name> or body.<path> where path is a dot
+// separated path into JSON body, e.g. body.alerts.0.labels.host, or a key of
+// form-encoded body. Expression without a known prefix is a query parameter.
 type WebhookResponseMap struct {
 	Message string `yaml:"message" json:"message"`
 	From    string `yaml:"from" json:"from"`
 	Chat    string `yaml:"chat" json:"chat"`
+
+	// Severity is the parameter with alert severity, rules of the listener are used if empty.
+	Severity string `yaml:"severity" json:"severity"`
+
+	// Status is the parameter with alert state, e.g. resolved; alerts are firing if empty.
+	Status string `yaml:"status" json:"status"`
+
+	// Template composes the message from several values instead of Message, e.g.
+	// {{ .Get "body.host" }}: {{ .Get "body.problem" }}.
+	Template string `yaml:"template" json:"template"`
+
+	// Required lists fields (message, from, chat, severity, status) the request
+	// is rejected without.
+	Required []string `yaml:"required" json:"required"`
 }
 
 type WebhookConfig struct {
 	Name        string             `yaml:"name" json:"name"`
 	Token       string             `yaml:"token" json:"token"`
+	Format      string             `yaml:"format" json:"format"`
 	ResponseMap WebhookResponseMap `yaml:"response_map" json:"response_map"`
+	Severity    *Severity          `yaml:"severity" json:"severity"`
+
+	// Auth authenticates requests in addition to the token, the token in the
+	// path may be omitted if it is set.
+	Auth *WebhookAuth `yaml:"auth" json:"auth"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -24,3 +74,64 @@ func (c *WebhookConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 	return nil
 }
+
+// Styles of webhook signatures.
+const (
+	// SignatureGitHub is sha256=<hex> in X-Hub-Signature-256 header, signed body.
+	SignatureGitHub = "github"
+
+	// SignatureSlack is v0=<hex> in X-Slack-Signature header, signed v0:<timestamp>:<body>
+	// with timestamp from X-Slack-Request-Timestamp header.
+	SignatureSlack = "slack"
+
+	// SignatureGrafana is <hex> in X-Grafana-Alerting-Signature header, signed body or
+	// <timestamp>:<body> if X-Grafana-Alerting-Timestamp header is sent.
+	SignatureGrafana = "grafana"
+)
+
+var DefaultWebhookSignature = WebhookSignature{
+	Style:     SignatureGitHub,
+	Tolerance: 5 * time.Minute,
+}
+
+// WebhookAuth lists checks requests have to pass, all configured checks are required.
+type WebhookAuth struct {
+	Signature   *WebhookSignature `yaml:"signature" json:"signature"`
+	BearerToken string            `yaml:"bearer_token" json:"bearer_token"`
+	BasicAuth   *BasicAuth        `yaml:"basic_auth" json:"basic_auth"`
+
+	// AllowedIPs lists addresses or CIDR ranges requests are accepted from.
+	AllowedIPs []string `yaml:"allowed_ips" json:"allowed_ips"`
+
+	// TrustForwardedFor takes client address from the last X-Forwarded-For entry,
+	// set it only behind a reverse proxy appending it.
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" json:"trust_forwarded_for"`
+}
+
+type BasicAuth struct {
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+}
+
+// WebhookSignature configures HMAC-SHA256 signature of the request body.
+type WebhookSignature struct {
+	Style  string `yaml:"style" json:"style"`
+	Secret string `yaml:"secret" json:"secret"`
+
+	// Header overrides the header with the signature of the style.
+	Header string `yaml:"header" json:"header"`
+
+	// Tolerance is the maximum age of signed timestamp, for styles signing it.
+	Tolerance time.Duration `yaml:"tolerance" json:"tolerance"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *WebhookSignature) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultWebhookSignature
+	type plain WebhookSignature
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,10 +1,28 @@
 package notifiers
 
-var DefaultWebhookConfig = WebhookConfig{}
+import "fmt"
+
+var DefaultWebhookConfig = WebhookConfig{
+	RetryStatusCodes: []string{"429", "5xx"},
+}
 
 type WebhookConfig struct {
+	// Name is referenced by routes, escalation steps and dead letter entries, "webhook" if empty.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
 	URL           string         `yaml:"url" json:"url"`
 	Authorization *Authorization `yaml:"authorization,omitempty" json:"authorization,omitempty"`
+
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify,omitempty"`
+
+	// RetryStatusCodes are response codes the delivery is retried on by the queue,
+	// x matches any digit, e.g. 5xx.
+	RetryStatusCodes []string `yaml:"retry_status_codes,omitempty" json:"retry_status_codes,omitempty"`
+
+	// Template is a Go text/template used to render the request body instead
+	// of the default JSON message, e.g. to fit Slack or PagerDuty payloads.
+	Template    string `yaml:"template,omitempty" json:"template,omitempty"`
+	ContentType string `yaml:"content_type,omitempty" json:"content_type,omitempty"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -15,5 +33,26 @@ func (c *WebhookConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
+	for _, code := range c.RetryStatusCodes {
+		if !validStatusCode(code) {
+			return fmt.Errorf("webhook %q: invalid retry status code %q, want 3 digits or x", c.Name, code)
+		}
+	}
+
 	return nil
 }
+
+// validStatusCode reports whether the code is a status code pattern like 429 or 5xx.
+func validStatusCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, c := range code {
+		if c != 'x' && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,28 @@
+package notifiers
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWebhookConfigRetryStatusCodes(t *testing.T) {
+	tests := []struct {
+		codes   string
+		wantErr bool
+	}{
+		{codes: "[429, 5xx]"},
+		{codes: "[50x]"},
+		{codes: "[5xxx]", wantErr: true},
+		{codes: "[5]", wantErr: true},
+		{codes: "[5XX]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var c WebhookConfig
+		err := yaml.Unmarshal([]byte("url: http://localhost\nretry_status_codes: "+tt.codes), &c)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("retry_status_codes %s: error %v, want error %v", tt.codes, err, tt.wantErr)
+		}
+	}
+}
@@ -7,6 +7,9 @@ var DefaultWebitelConfig = WebitelConfig{
 }
 
 type WebitelConfig struct {
+	// Name is referenced by routes, escalation steps and dead letter entries, "webitel" if empty.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
 	URL           string         `yaml:"url" json:"url"`
 	Authorization *Authorization `yaml:"authorization,omitempty" json:"authorization,omitempty"`
 
@@ -0,0 +1,39 @@
+package config
+
+import "time"
+
+// Team is a group of technicals paged together.
+type Team struct {
+	Name       string   `yaml:"name" json:"name"`
+	Technicals []string `yaml:"technicals" json:"technicals"`
+}
+
+// Route is a node of the routing tree. Alert is passed to the first child route
+// it matches (or to every matching child with Continue set), the deepest matching
+// routes decide how the alert is notified. Unset settings are inherited from
+// the parent route; the root route matches all alerts.
+type Route struct {
+	// Match requires alert fields (channel, chat, from, text, severity) to be equal to the values.
+	Match map[string]string `yaml:"match" json:"match"`
+
+	// MatchRE requires alert fields to match the regular expressions.
+	MatchRE map[string]string `yaml:"match_re" json:"match_re"`
+
+	// Notifiers lists names of notifiers used for matched alerts.
+	Notifiers []string `yaml:"notifiers" json:"notifiers"`
+
+	// Technical or Team is paged instead of the technical on duty.
+	Technical string `yaml:"technical" json:"technical"`
+	Team      string `yaml:"team" json:"team"`
+
+	GroupBy          []string      `yaml:"group_by" json:"group_by"`
+	GroupWait        time.Duration `yaml:"group_wait" json:"group_wait"`
+	GroupInterval    time.Duration `yaml:"group_interval" json:"group_interval"`
+	RepeatInterval   time.Duration `yaml:"repeat_interval" json:"repeat_interval"`
+	EscalationPolicy string        `yaml:"escalation_policy" json:"escalation_policy"`
+
+	// Continue evaluates following sibling routes after this one matched.
+	Continue bool `yaml:"continue" json:"continue"`
+
+	Routes []*Route `yaml:"routes" json:"routes"`
+}
@@ -0,0 +1,42 @@
+package config
+
+// Schedule defines on-call rotations used to choose the technical on duty.
+type Schedule struct {
+	// Layers are rotations, later layers take precedence over earlier ones
+	// during their window, e.g. to build follow-the-sun schedules.
+	Layers []*RotationLayer `yaml:"layers" json:"layers"`
+
+	// Overrides replace the technical on duty for the given period, e.g. holidays.
+	Overrides []*ScheduleOverride `yaml:"overrides" json:"overrides"`
+
+	// ManualOverride keeps choosing the technical in the manager bot, the choice
+	// replaces the scheduled technical until the next start.
+	ManualOverride bool `yaml:"manual_override" json:"manual_override"`
+}
+
+type RotationLayer struct {
+	Name string `yaml:"name" json:"name"`
+
+	// Start is the time of the first handoff in the configured timezone,
+	// formatted as 2006-01-02T15:04.
+	Start string `yaml:"start" json:"start"`
+
+	// Rotation is daily, weekly or a duration of each shift, e.g. 12h.
+	Rotation string `yaml:"rotation" json:"rotation"`
+
+	// Technicals are names of technicals in rotation order.
+	Technicals []string `yaml:"technicals" json:"technicals"`
+
+	// From and To restrict the layer to the time of day, formatted as 15:04.
+	// The window may wrap over midnight, e.g. from 21:00 to 09:00.
+	From string `yaml:"from" json:"from"`
+	To   string `yaml:"to" json:"to"`
+}
+
+type ScheduleOverride struct {
+	Technical string `yaml:"technical" json:"technical"`
+
+	// Start and End of the override in the configured timezone, formatted as 2006-01-02T15:04.
+	Start string `yaml:"start" json:"start"`
+	End   string `yaml:"end" json:"end"`
+}
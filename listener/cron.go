@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/go-co-op/gocron"
+	"github.com/robfig/cron/v3"
 	"github.com/webitel/wlog"
 )
 
@@ -38,3 +39,45 @@ func (s *Scheduler) ScheduleJob(interval string, jobTag string, jobFun interface
 
 	return job, nil
 }
+
+// ActiveWindow reports whether the given time is between one of the start and
+// stop jobs, i.e. the next stop comes earlier than the next start, and returns
+// the end of the window.
+func (s *Scheduler) ActiveWindow(starts, stops []string, now time.Time) (time.Time, bool, error) {
+	nextStart, err := s.next(starts, now)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	nextStop, err := s.next(stops, now)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if nextStart.IsZero() || nextStop.IsZero() {
+		return time.Time{}, false, nil
+	}
+
+	if nextStop.Before(nextStart) {
+		return nextStop, true, nil
+	}
+
+	return time.Time{}, false, nil
+}
+
+// next returns the earliest next run of the intervals after the given time.
+func (s *Scheduler) next(intervals []string, now time.Time) (time.Time, error) {
+	var next time.Time
+	for _, interval := range intervals {
+		schedule, err := cron.ParseStandard(fmt.Sprintf("CRON_TZ=%s %s", s.cron.Location().String(), interval))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse interval %q: %v", interval, err)
+		}
+
+		if t := schedule.Next(now); next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+
+	return next, nil
+}
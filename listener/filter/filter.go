@@ -0,0 +1,174 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// Reasons messages are dropped for.
+const (
+	ReasonSender  = "sender"
+	ReasonChat    = "chat"
+	ReasonExclude = "exclude"
+	ReasonInclude = "include"
+)
+
+// Filter decides whether incoming message is pushed to the queue and counts
+// dropped messages by reason. Nil filter allows all messages.
+type Filter struct {
+	log *wlog.Logger
+
+	includeKeywords []string
+	includeRegex    []*regexp.Regexp
+	excludeKeywords []string
+	excludeRegex    []*regexp.Regexp
+	allowSenders    []string
+	denySenders     []string
+	allowChats      []string
+
+	mu      sync.Mutex
+	dropped map[string]uint64
+}
+
+func New(cfg *listeners.Filter, log *wlog.Logger) (*Filter, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	f := &Filter{
+		log:             log,
+		includeKeywords: lower(cfg.IncludeKeywords),
+		excludeKeywords: lower(cfg.ExcludeKeywords),
+		allowSenders:    cfg.AllowSenders,
+		denySenders:     cfg.DenySenders,
+		allowChats:      cfg.AllowChats,
+		dropped:         make(map[string]uint64),
+	}
+
+	var err error
+	if f.includeRegex, err = compile(cfg.IncludeRegex); err != nil {
+		return nil, fmt.Errorf("include regex: %v", err)
+	}
+
+	if f.excludeRegex, err = compile(cfg.ExcludeRegex); err != nil {
+		return nil, fmt.Errorf("exclude regex: %v", err)
+	}
+
+	return f, nil
+}
+
+// Allow reports whether the alert passes the filter.
+func (f *Filter) Allow(alert *model.Alert) bool {
+	if f == nil {
+		return true
+	}
+
+	if reason := f.reason(alert); reason != "" {
+		f.mu.Lock()
+		f.dropped[reason]++
+		count := f.dropped[reason]
+		f.mu.Unlock()
+
+		f.log.Debug("drop message", wlog.String("reason", reason), wlog.String("chat", alert.Chat),
+			wlog.String("from", alert.From), wlog.Any("dropped", count))
+
+		return false
+	}
+
+	return true
+}
+
+// Dropped returns count of dropped messages by reason.
+func (f *Filter) Dropped() map[string]uint64 {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dropped := make(map[string]uint64, len(f.dropped))
+	for reason, count := range f.dropped {
+		dropped[reason] = count
+	}
+
+	return dropped
+}
+
+func (f *Filter) reason(alert *model.Alert) string {
+	if slices.Contains(f.denySenders, alert.From) {
+		return ReasonSender
+	}
+
+	if len(f.allowSenders) > 0 && !slices.Contains(f.allowSenders, alert.From) {
+		return ReasonSender
+	}
+
+	if len(f.allowChats) > 0 && !slices.Contains(f.allowChats, alert.Chat) {
+		return ReasonChat
+	}
+
+	text := strings.ToLower(alert.Text)
+	if containsAny(text, f.excludeKeywords) || matchesAny(alert.Text, f.excludeRegex) {
+		return ReasonExclude
+	}
+
+	if len(f.includeKeywords) > 0 || len(f.includeRegex) > 0 {
+		if !containsAny(text, f.includeKeywords) && !matchesAny(alert.Text, f.includeRegex) {
+			return ReasonInclude
+		}
+	}
+
+	return ""
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesAny(text string, regex []*regexp.Regexp) bool {
+	for _, re := range regex {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lower(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, strings.ToLower(v))
+	}
+
+	return out
+}
+
+func compile(exprs []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, re)
+	}
+
+	return out, nil
+}
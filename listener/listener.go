@@ -5,8 +5,10 @@ import (
 
 	"github.com/webitel/wlog"
 
+	"github.com/kirychukyurii/notificator/attachment"
 	"github.com/kirychukyurii/notificator/config"
 	"github.com/kirychukyurii/notificator/listener/skype"
+	"github.com/kirychukyurii/notificator/listener/slack"
 	"github.com/kirychukyurii/notificator/listener/teams"
 	"github.com/kirychukyurii/notificator/listener/telegram"
 	"github.com/kirychukyurii/notificator/listener/webhook"
@@ -40,14 +42,25 @@ func NewListeners(log *wlog.Logger, cfg *config.Config, queue *notifier.Queue, s
 		}
 	)
 
+	store, err := attachment.NewStore(log.With(wlog.String("component", "attachments")), cfg.Attachments, cfg.SessionsDir, srv)
+	if err != nil {
+		log.Error("skip attachment store", wlog.Err(err))
+	}
+
 	for _, c := range cfg.Listeners.TelegramConfigs {
-		add("telegram", c.Phone, func(l *wlog.Logger) (Listener, error) { return telegram.New(c, cfg.SessionsDir, l, queue) })
+		add("telegram", c.Phone, func(l *wlog.Logger) (Listener, error) {
+			return telegram.New(c, cfg.SessionsDir, l, queue, srv, store)
+		})
 	}
 
 	for _, c := range cfg.Listeners.SkypeConfigs {
 		add("skype", c.Login, func(l *wlog.Logger) (Listener, error) { return skype.New(c, l, queue) })
 	}
 
+	for i, c := range cfg.Listeners.SlackConfigs {
+		add("slack", i, func(l *wlog.Logger) (Listener, error) { return slack.New(c, l, queue) })
+	}
+
 	if len(cfg.Listeners.WebhookConfigs) > 0 {
 		handler := webhook.NewHandler(srv)
 		for _, c := range cfg.Listeners.WebhookConfigs {
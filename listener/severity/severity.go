@@ -0,0 +1,89 @@
+package severity
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// Classifier assigns severity to alerts by the text rules of the listener.
+// Nil classifier leaves alerts as is.
+type Classifier struct {
+	def   model.Severity
+	rules []*rule
+}
+
+type rule struct {
+	severity model.Severity
+	keywords []string
+	regex    *regexp.Regexp
+}
+
+func New(cfg *listeners.Severity) (*Classifier, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	c := &Classifier{}
+	if cfg.Default != "" {
+		def, err := model.ParseSeverity(cfg.Default)
+		if err != nil {
+			return nil, fmt.Errorf("default: %v", err)
+		}
+
+		c.def = def
+	}
+
+	for i, r := range cfg.Rules {
+		s, err := model.ParseSeverity(r.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i, err)
+		}
+
+		parsed := &rule{severity: s}
+		for _, k := range r.Keywords {
+			parsed.keywords = append(parsed.keywords, strings.ToLower(k))
+		}
+
+		if r.Regex != "" {
+			if parsed.regex, err = regexp.Compile(r.Regex); err != nil {
+				return nil, fmt.Errorf("rule %d: %v", i, err)
+			}
+		}
+
+		c.rules = append(c.rules, parsed)
+	}
+
+	return c, nil
+}
+
+// Apply sets severity of the alert unless it is already set, e.g. from the webhook payload.
+func (c *Classifier) Apply(alert *model.Alert) {
+	if c == nil || alert.Severity != "" {
+		return
+	}
+
+	text := strings.ToLower(alert.Text)
+	for _, r := range c.rules {
+		if r.matches(alert.Text, text) {
+			alert.Severity = r.severity
+
+			return
+		}
+	}
+
+	alert.Severity = c.def
+}
+
+func (r *rule) matches(text, lower string) bool {
+	for _, k := range r.keywords {
+		if strings.Contains(lower, k) {
+			return true
+		}
+	}
+
+	return r.regex != nil && r.regex.MatchString(text)
+}
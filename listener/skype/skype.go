@@ -3,24 +3,39 @@ package skype
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/webitel/wlog"
 
 	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/listener/filter"
+	"github.com/kirychukyurii/notificator/listener/severity"
 	"github.com/kirychukyurii/notificator/listener/skype/client"
 	"github.com/kirychukyurii/notificator/model"
 	"github.com/kirychukyurii/notificator/notifier"
 )
 
 type Manager struct {
-	log   *wlog.Logger
-	queue *notifier.Queue
-	cli   *client.Client
+	log      *wlog.Logger
+	queue    *notifier.Queue
+	filter   *filter.Filter
+	severity *severity.Classifier
+	cli      *client.Client
 
 	stopFunc context.CancelFunc
 }
 
 func New(cfg *listeners.SkypeConfig, log *wlog.Logger, queue *notifier.Queue) (*Manager, error) {
+	f, err := filter.New(cfg.Filter, log)
+	if err != nil {
+		return nil, fmt.Errorf("filter: %v", err)
+	}
+
+	classifier, err := severity.New(cfg.Severity)
+	if err != nil {
+		return nil, fmt.Errorf("severity: %v", err)
+	}
+
 	c, err := client.New(log, cfg.Login, cfg.Password)
 	if err != nil {
 		return nil, err
@@ -36,13 +51,15 @@ func New(cfg *listeners.SkypeConfig, log *wlog.Logger, queue *notifier.Queue) (*
 	return &Manager{
 		log:      log,
 		queue:    queue,
+		filter:   f,
+		severity: classifier,
 		cli:      c,
 		stopFunc: stopFunc,
 	}, nil
 }
 
 func (m *Manager) Listen(ctx context.Context) error {
-	m.cli.AddHandler(newHandler(m.queue))
+	m.cli.AddHandler(newHandler(m.queue, m.filter, m.severity))
 
 	select {
 	case <-ctx.Done():
@@ -60,21 +77,32 @@ func (m *Manager) String() string {
 
 func (m *Manager) Close() error {
 	m.cli.ClearHandlers()
+	if dropped := m.filter.Dropped(); len(dropped) > 0 {
+		m.log.Info("messages dropped by filter", wlog.Any("dropped", dropped))
+	}
 
 	return nil
 }
 
-func newHandler(queue *notifier.Queue) client.Handler {
+func newHandler(queue *notifier.Queue, f *filter.Filter, c *severity.Classifier) client.Handler {
 	return func(message *client.Resource) {
 		if message.MessageType == "RichText" || message.MessageType == "Text" {
+			alert := &model.Alert{
+				Channel: "skype",
+				Text:    message.Content,
+				From:    message.ImDisplayName,
+				Chat:    message.ThreadTopic,
+			}
+
+			if !f.Allow(alert) {
+				return
+			}
+
+			c.Apply(alert)
+
 			queue.Push(&notifier.Message{
 				Channel: "skype",
-				Content: &model.Alert{
-					Channel: "skype",
-					Text:    message.Content,
-					From:    message.ImDisplayName,
-					Chat:    message.ThreadTopic,
-				},
+				Content: alert,
 			})
 		}
 	}
@@ -3,6 +3,8 @@ package slack
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
@@ -10,30 +12,70 @@ import (
 	"github.com/webitel/wlog"
 
 	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/listener/severity"
+	"github.com/kirychukyurii/notificator/model"
+	"github.com/kirychukyurii/notificator/notifier"
 )
 
 type Manager struct {
-	log  *wlog.Logger
-	conn *socketmode.Client
+	log   *wlog.Logger
+	queue *notifier.Queue
+	cli   *slack.Client
+	conn  *socketmode.Client
+
+	severity *severity.Classifier
+
+	// botID is the user ID of the bot, used to distinguish app mentions
+	// from regular messages.
+	botID string
+
+	mu       sync.RWMutex
+	users    map[string]string
+	channels map[string]string
+
+	stopFunc context.CancelFunc
 }
 
-func New(cfg *listeners.SlackConfig, log *wlog.Logger) (*Manager, error) {
-	cli := slack.New(cfg.BotToken, slack.OptionDebug(true), slack.OptionAppLevelToken(cfg.AppToken))
-	conn := socketmode.New(cli, socketmode.OptionDebug(true))
+func New(cfg *listeners.SlackConfig, log *wlog.Logger, queue *notifier.Queue) (*Manager, error) {
+	classifier, err := severity.New(cfg.Severity)
+	if err != nil {
+		return nil, fmt.Errorf("severity: %v", err)
+	}
+
+	cli := slack.New(cfg.BotToken, slack.OptionAppLevelToken(cfg.AppToken))
+	resp, err := cli.AuthTest()
+	if err != nil {
+		return nil, fmt.Errorf("slack auth test: %w", err)
+	}
+
+	log.Info("logged bot", wlog.String("user", resp.User), wlog.String("team", resp.Team), wlog.String("id", resp.UserID))
 
 	return &Manager{
-		log:  log,
-		conn: conn,
+		log:      log,
+		queue:    queue,
+		cli:      cli,
+		conn:     socketmode.New(cli),
+		severity: classifier,
+		botID:    resp.UserID,
+		users:    make(map[string]string),
+		channels: make(map[string]string),
 	}, nil
 }
 
 func (m *Manager) Listen(ctx context.Context) error {
-	h := socketmode.NewSocketmodeHandler(m.conn)
+	ctx, m.stopFunc = context.WithCancel(ctx)
 
-	h.Handle(socketmode.EventTypeEventsAPI, middlewareEventsAPI)
+	h := socketmode.NewSocketmodeHandler(m.conn)
 	h.HandleEvents(slackevents.Message, m.receiveMessageEvent)
+	h.HandleEvents(slackevents.AppMention, m.receiveAppMentionEvent)
+	h.HandleDefault(m.receiveDefault)
+
+	m.log.Info("start listening")
+	if err := h.RunEventLoopContext(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
 
-	return h.RunEventLoopContext(ctx)
+	return nil
 }
 
 func (m *Manager) String() string {
@@ -41,51 +83,169 @@ func (m *Manager) String() string {
 }
 
 func (m *Manager) Close() error {
+	if m.stopFunc != nil {
+		m.stopFunc()
+	}
+
 	return nil
 }
 
 func (m *Manager) receiveMessageEvent(evt *socketmode.Event, client *socketmode.Client) {
-	eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
+	ev, ok := m.innerEvent(evt, client).(*slackevents.MessageEvent)
 	if !ok {
 		return
 	}
 
-	client.Ack(*evt.Request)
+	// Skip edits, deletions, joins and other service messages, as well as
+	// messages sent by bots (including ourselves).
+	if ev.SubType != "" || ev.BotID != "" || ev.User == "" || ev.User == m.botID {
+		return
+	}
+
+	// Mentions of the bot are delivered as a separate app_mention event.
+	if strings.Contains(ev.Text, "<@"+m.botID+">") {
+		return
+	}
+
+	m.push(ev.Channel, ev.User, ev.Text, ev.TimeStamp, ev.ThreadTimeStamp)
+}
 
-	ev, ok := eventsAPIEvent.InnerEvent.Data.(*slackevents.MessageEvent)
+func (m *Manager) receiveAppMentionEvent(evt *socketmode.Event, client *socketmode.Client) {
+	ev, ok := m.innerEvent(evt, client).(*slackevents.AppMentionEvent)
 	if !ok {
 		return
 	}
 
-	m.log.Debug("received message", wlog.String("message", ev.Text), wlog.String("channel", ev.Channel))
+	if ev.BotID != "" {
+		return
+	}
+
+	m.push(ev.Channel, ev.User, ev.Text, ev.TimeStamp, ev.ThreadTimeStamp)
+}
+
+func (m *Manager) receiveDefault(evt *socketmode.Event, client *socketmode.Client) {
+	switch evt.Type {
+	case socketmode.EventTypeConnecting:
+		m.log.Debug("connecting to slack with socket mode")
+	case socketmode.EventTypeConnectionError:
+		m.log.Warn("connection to slack failed, retrying")
+	case socketmode.EventTypeConnected:
+		m.log.Info("connected to slack with socket mode")
+	default:
+		if evt.Request != nil && evt.Request.EnvelopeID != "" {
+			client.Ack(*evt.Request)
+		}
+
+		m.log.Debug("unsupported event received", wlog.String("type", string(evt.Type)))
+	}
 }
 
-func middlewareEventsAPI(evt *socketmode.Event, client *socketmode.Client) {
-	fmt.Println("middlewareEventsAPI")
+// innerEvent acknowledges Events API envelope and returns its inner event data.
+func (m *Manager) innerEvent(evt *socketmode.Event, client *socketmode.Client) any {
 	eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
 	if !ok {
-		fmt.Printf("Ignored %+v\n", evt)
-		return
+		return nil
+	}
+
+	if evt.Request != nil {
+		client.Ack(*evt.Request)
+	}
+
+	if eventsAPIEvent.Type != slackevents.CallbackEvent {
+		return nil
 	}
 
-	fmt.Printf("Event received: %+v\n", eventsAPIEvent)
+	return eventsAPIEvent.InnerEvent.Data
+}
 
-	client.Ack(*evt.Request)
+func (m *Manager) push(channel, user, text, ts, threadTS string) {
+	ctx := context.Background()
+	alert := &model.Alert{
+		Channel: "slack",
+		Text:    text,
+		From:    m.userName(ctx, user),
+		Chat:    m.channelName(ctx, channel),
+	}
 
-	switch eventsAPIEvent.Type {
-	case slackevents.CallbackEvent:
-		innerEvent := eventsAPIEvent.InnerEvent
-		switch ev := innerEvent.Data.(type) {
-		case *slackevents.AppMentionEvent:
-			fmt.Printf("We have been mentionned in %v", ev.Channel)
-			_, _, err := client.Client.PostMessage(ev.Channel, slack.MsgOptionText("Yes, hello.", false))
-			if err != nil {
-				fmt.Printf("failed posting message: %v", err)
-			}
-		case *slackevents.MemberJoinedChannelEvent:
-			fmt.Printf("user %q joined to channel %q", ev.User, ev.Channel)
+	if threadTS != "" {
+		link, err := m.cli.GetPermalinkContext(ctx, &slack.PermalinkParameters{Channel: channel, Ts: threadTS})
+		if err != nil {
+			m.log.Warn("resolve thread permalink", wlog.Err(err), wlog.String("channel", channel), wlog.String("thread", threadTS))
+			link = threadTS
 		}
+
+		alert.Thread = link
+	}
+
+	m.severity.Apply(alert)
+	m.log.Debug("received message", wlog.String("channel", alert.Chat), wlog.String("from", alert.From), wlog.String("ts", ts))
+	m.queue.Push(&notifier.Message{
+		Channel: "slack",
+		Content: alert,
+	})
+}
+
+// userName resolves the display name of the user, falling back to its real name and ID.
+func (m *Manager) userName(ctx context.Context, id string) string {
+	m.mu.RLock()
+	name, ok := m.users[id]
+	m.mu.RUnlock()
+	if ok {
+		return name
+	}
+
+	u, err := m.cli.GetUserInfoContext(ctx, id)
+	if err != nil {
+		m.log.Warn("resolve user", wlog.Err(err), wlog.String("user", id))
+
+		return id
+	}
+
+	name = u.Profile.DisplayName
+	if name == "" {
+		name = u.RealName
+	}
+
+	if name == "" {
+		name = u.Name
+	}
+
+	m.mu.Lock()
+	m.users[id] = name
+	m.mu.Unlock()
+
+	return name
+}
+
+// channelName resolves the name of the conversation; direct messages have no name,
+// so they are named after the peer user.
+func (m *Manager) channelName(ctx context.Context, id string) string {
+	m.mu.RLock()
+	name, ok := m.channels[id]
+	m.mu.RUnlock()
+	if ok {
+		return name
+	}
+
+	c, err := m.cli.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: id})
+	if err != nil {
+		m.log.Warn("resolve channel", wlog.Err(err), wlog.String("channel", id))
+
+		return id
+	}
+
+	switch {
+	case c.IsIM:
+		name = "@" + m.userName(ctx, c.User)
+	case c.Name != "":
+		name = "#" + c.Name
 	default:
-		client.Debugf("unsupported Events API event received")
+		name = id
 	}
+
+	m.mu.Lock()
+	m.channels[id] = name
+	m.mu.Unlock()
+
+	return name
 }
@@ -0,0 +1,126 @@
+package teams
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	msgraphmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/model"
+)
+
+var (
+	// resourceRE matches resource of the chat message notification, e.g. chats('19:id@thread.v2')/messages('1616990032035').
+	resourceRE = regexp.MustCompile(`^/?chats\('([^']+)'\)/messages\('([^']+)'\)$`)
+
+	tagRE   = regexp.MustCompile(`<[^>]*>`)
+	spaceRE = regexp.MustCompile(`[ \t]+`)
+)
+
+// alert fetches the message the notification is about and builds the alert
+// from it. It returns nil alert for messages which are not sent by users, e.g.
+// system events.
+func (m *Manager) alert(ctx context.Context, n NotificationItem) (*model.Alert, error) {
+	match := resourceRE.FindStringSubmatch(n.Resource)
+	if match == nil {
+		return nil, fmt.Errorf("unexpected resource %q", n.Resource)
+	}
+
+	chatID, messageID := match[1], match[2]
+	msg, err := m.cli.Chats().ByChatId(chatID).Messages().ByChatMessageId(messageID).Get(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get message: %w", err)
+	}
+
+	if t := msg.GetMessageType(); t != nil && *t != msgraphmodels.MESSAGE_CHATMESSAGETYPE {
+		return nil, nil
+	}
+
+	alert := &model.Alert{
+		Channel:   "teams",
+		Chat:      m.chatName(ctx, chatID),
+		MessageID: messageID,
+		Labels:    map[string]string{"chat_id": chatID},
+	}
+
+	if body := msg.GetBody(); body != nil && body.GetContent() != nil {
+		alert.Text = *body.GetContent()
+		if t := body.GetContentType(); t != nil && *t == msgraphmodels.HTML_BODYTYPE {
+			alert.Text = plainText(alert.Text)
+		}
+	}
+
+	if from := msg.GetFrom(); from != nil {
+		identity := from.GetUser()
+		if identity == nil {
+			identity = from.GetApplication()
+		}
+
+		if identity != nil {
+			alert.From = deref(identity.GetDisplayName())
+			if id := deref(identity.GetId()); id != "" {
+				alert.Labels["user_id"] = id
+			}
+		}
+	}
+
+	alert.Link = deref(msg.GetWebUrl())
+
+	return alert, nil
+}
+
+// chatName returns the topic of the chat, the chat ID if the chat has no topic
+// or it could not be fetched. Topics are cached, so the chat is fetched once.
+func (m *Manager) chatName(ctx context.Context, id string) string {
+	m.chatsMu.Lock()
+	name, ok := m.chats[id]
+	m.chatsMu.Unlock()
+	if ok {
+		return name
+	}
+
+	chat, err := m.cli.Chats().ByChatId(id).Get(ctx, nil)
+	if err != nil {
+		m.log.Warn("get chat, use ID as its name", wlog.Err(err), wlog.String("chat", id))
+
+		return id
+	}
+
+	if name = deref(chat.GetTopic()); name == "" {
+		name = id
+	}
+
+	m.chatsMu.Lock()
+	m.chats[id] = name
+	m.chatsMu.Unlock()
+
+	return name
+}
+
+// plainText strips HTML markup of the message body.
+func plainText(s string) string {
+	s = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n", "</div>", "\n").Replace(s)
+	s = html.UnescapeString(tagRE.ReplaceAllString(s, ""))
+
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	for _, l := range lines {
+		if l = strings.TrimSpace(spaceRE.ReplaceAllString(l, " ")); l != "" {
+			out = append(out, l)
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,24 +19,44 @@ import (
 	"github.com/webitel/wlog"
 
 	"github.com/kirychukyurii/notificator/config/listeners"
-	"github.com/kirychukyurii/notificator/model"
+	"github.com/kirychukyurii/notificator/listener/filter"
+	"github.com/kirychukyurii/notificator/listener/severity"
 	"github.com/kirychukyurii/notificator/notifier"
 	"github.com/kirychukyurii/notificator/server"
 )
 
+// messageTimeout limits fetching of the message the notification is about.
+const messageTimeout = 30 * time.Second
+
 type Manager struct {
 	log *wlog.Logger
 
-	auth  *auth
-	queue *notifier.Queue
+	auth     *auth
+	queue    *notifier.Queue
+	filter   *filter.Filter
+	severity *severity.Classifier
 
 	cli       *msgraphsdk.GraphServiceClient
 	subsID    string
 	subsState string // to check that the change notification came from the subscription
 	subsURL   string
+
+	// chats caches topics of chats by their IDs.
+	chatsMu sync.Mutex
+	chats   map[string]string
 }
 
 func New(cfg *listeners.TeamsConfig, log *wlog.Logger, queue *notifier.Queue, srv *server.Server, sessionDir string) (*Manager, error) {
+	f, err := filter.New(cfg.Filter, log)
+	if err != nil {
+		return nil, fmt.Errorf("filter: %v", err)
+	}
+
+	classifier, err := severity.New(cfg.Severity)
+	if err != nil {
+		return nil, fmt.Errorf("severity: %v", err)
+	}
+
 	ctx := context.Background()
 	authcli, err := newAuth(ctx, cfg, log, srv, queue, sessionDir)
 	if err != nil {
@@ -53,9 +74,12 @@ func New(cfg *listeners.TeamsConfig, log *wlog.Logger, queue *notifier.Queue, sr
 		log:       log,
 		auth:      authcli,
 		queue:     queue,
+		filter:    f,
+		severity:  classifier,
 		cli:       cli,
 		subsState: uuid.New().String(),
 		subsURL:   srv.PublicURL() + "/subscription",
+		chats:     make(map[string]string),
 	}
 
 	srv.HandleFunc("/subscription/{subs_state}", m.handleSubsCallback)
@@ -75,6 +99,10 @@ func (m *Manager) String() string {
 }
 
 func (m *Manager) Close() error {
+	if dropped := m.filter.Dropped(); len(dropped) > 0 {
+		m.log.Info("messages dropped by filter", wlog.Any("dropped", dropped))
+	}
+
 	if m.subsID != "" {
 		if err := m.cli.Subscriptions().BySubscriptionId(m.subsID).Delete(context.TODO(), nil); err != nil {
 			return fmt.Errorf("delete subscription: %w", err)
@@ -197,19 +225,38 @@ func (m *Manager) handleSubsCallback(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		m.queue.Push(&notifier.Message{
-			Channel: "teams",
-			Content: &model.Alert{
-				Channel: "teams",
-				// TODO: add other fields
-			},
-		})
-
+		// Message is fetched in background, so Graph does not wait for the response and retry.
+		go m.push(n)
 	}
 
 	w.WriteHeader(http.StatusAccepted)
 }
 
+// push fetches the message of the notification and pushes it to the queue if
+// it passes the filter.
+func (m *Manager) push(n NotificationItem) {
+	ctx, cancel := context.WithTimeout(context.Background(), messageTimeout)
+	defer cancel()
+
+	alert, err := m.alert(ctx, n)
+	if err != nil {
+		m.log.Error("build alert from notification", wlog.Err(err), wlog.String("resource", n.Resource))
+
+		return
+	}
+
+	if alert == nil || !m.filter.Allow(alert) {
+		return
+	}
+
+	m.severity.Apply(alert)
+
+	m.queue.Push(&notifier.Message{
+		Channel: alert.Channel,
+		Content: alert,
+	})
+}
+
 func toPTR[T any](val T) *T {
 	return &val
 }
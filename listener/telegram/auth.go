@@ -3,14 +3,25 @@ package telegram
 import (
 	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"html/template"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gotd/td/telegram/auth"
 	"github.com/gotd/td/tg"
+	"github.com/webitel/wlog"
 	"golang.org/x/term"
+
+	"github.com/kirychukyurii/notificator/model"
+	"github.com/kirychukyurii/notificator/notifier"
+	"github.com/kirychukyurii/notificator/server"
 )
 
 type Auth struct {
@@ -58,3 +69,194 @@ func (Auth) Password(_ context.Context) (string, error) {
 
 	return strings.TrimSpace(string(bytePwd)), nil
 }
+
+// loginForm is the one-time form to enter login code or 2FA password.
+var loginForm = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Telegram login</title></head>
+<body>
+<form method="post">
+<label>Telegram {{ .Account }} login {{ .Kind }}:
+<input name="secret" type="{{ if eq .Kind "password" }}password{{ else }}text{{ end }}" autocomplete="off" autofocus required>
+</label>
+<button type="submit">Log in</button>
+</form>
+</body>
+</html>
+`))
+
+// remoteAuth requests login code and 2FA password via the manager bot and the
+// one-time HTTP form, so login does not require a terminal.
+type remoteAuth struct {
+	Auth
+
+	log     *wlog.Logger
+	queue   *notifier.Queue
+	formURL string
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*model.AuthRequest
+	// last is ID of the last request, its message reports the login result.
+	last string
+}
+
+func newRemoteAuth(phone string, log *wlog.Logger, queue *notifier.Queue, srv *server.Server, timeout time.Duration) *remoteAuth {
+	a := &remoteAuth{
+		Auth:    Auth{phone: phone},
+		log:     log,
+		queue:   queue,
+		timeout: timeout,
+		pending: make(map[string]*model.AuthRequest),
+	}
+
+	path := "/telegram/" + sessionFolder(phone) + "/login"
+	a.formURL = srv.PublicURL() + path
+	srv.HandleFunc(path+"/{id}", a.handleForm)
+
+	return a
+}
+
+func (a *remoteAuth) Code(ctx context.Context, _ *tg.AuthSentCode) (string, error) {
+	return a.request(ctx, model.AuthCode)
+}
+
+func (a *remoteAuth) Password(ctx context.Context) (string, error) {
+	return a.request(ctx, model.AuthPassword)
+}
+
+// request asks for the secret and waits for it until the timeout.
+func (a *remoteAuth) request(ctx context.Context, kind string) (string, error) {
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+
+	reply := make(chan string, 1)
+	req := &model.AuthRequest{
+		ID:      id,
+		Account: a.phone,
+		Kind:    kind,
+		FormURL: a.formURL + "/" + id,
+		Reply:   reply,
+	}
+
+	a.mu.Lock()
+	previous := a.last
+	a.pending[id] = req
+	a.last = id
+	a.mu.Unlock()
+
+	// Secret of the previous request is accepted, the new one reports the result.
+	if previous != "" {
+		a.status(previous, "accepted, "+kind+" required", true)
+	}
+
+	defer func() {
+		a.mu.Lock()
+		delete(a.pending, id)
+		a.mu.Unlock()
+	}()
+
+	a.log.Info("wait for login secret", wlog.String("kind", kind), wlog.String("form", req.FormURL))
+	a.queue.Push(&notifier.Message{Channel: "telegram", Content: req})
+
+	timer := time.NewTimer(a.timeout)
+	defer timer.Stop()
+
+	select {
+	case secret := <-reply:
+		a.status(id, kind+" received, logging in", false)
+
+		return secret, nil
+	case <-timer.C:
+		a.status(id, kind+" not entered in "+a.timeout.String(), true)
+
+		return "", fmt.Errorf("login %s not entered in %s", kind, a.timeout)
+	case <-ctx.Done():
+		a.status(id, "cancelled", true)
+
+		return "", ctx.Err()
+	}
+}
+
+// finish reports the result of the login to the last request.
+func (a *remoteAuth) finish(err error) {
+	a.mu.Lock()
+	id := a.last
+	a.last = ""
+	a.mu.Unlock()
+
+	if id == "" {
+		// Session was valid, nothing was requested.
+		return
+	}
+
+	if err != nil {
+		a.status(id, "failed: "+err.Error(), true)
+
+		return
+	}
+
+	a.status(id, "logged in", true)
+}
+
+func (a *remoteAuth) status(id, text string, done bool) {
+	a.queue.Push(&notifier.Message{
+		Channel: "telegram",
+		Content: &model.AuthStatus{ID: id, Text: text, Done: done},
+	})
+}
+
+// handleForm serves the one-time form of the pending request, the form is
+// gone once the secret is submitted or the request is expired.
+func (a *remoteAuth) handleForm(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	req, ok := a.pending[r.PathValue("id")]
+	a.mu.Unlock()
+	if !ok {
+		http.Error(w, "login request not found or expired", http.StatusNotFound)
+
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := loginForm.Execute(w, req); err != nil {
+			a.log.Error("render login form", wlog.Err(err))
+		}
+	case http.MethodPost:
+		secret := strings.TrimSpace(r.PostFormValue("secret"))
+		if secret == "" {
+			http.Error(w, "secret required", http.StatusBadRequest)
+
+			return
+		}
+
+		a.mu.Lock()
+		delete(a.pending, req.ID)
+		a.mu.Unlock()
+
+		select {
+		case req.Reply <- secret:
+		default:
+			http.Error(w, "login request is already answered", http.StatusConflict)
+
+			return
+		}
+
+		w.Write([]byte("Accepted, logging in. The status is reported in the manager chat and logs."))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tg"
+	"github.com/webitel/wlog"
+)
+
+// chatRules decides which messages are received by chat, mentions and mute
+// settings of the account.
+type chatRules struct {
+	chats        map[string]struct{}
+	mentionsOnly bool
+	ignoreMuted  bool
+
+	mu sync.Mutex
+	// muted holds mute deadlines of chats by peer key.
+	muted map[string]time.Time
+}
+
+func newChatRules(chats []string, mentionsOnly, ignoreMuted bool) *chatRules {
+	r := &chatRules{
+		mentionsOnly: mentionsOnly,
+		ignoreMuted:  ignoreMuted,
+		muted:        make(map[string]time.Time),
+	}
+
+	if len(chats) > 0 {
+		r.chats = make(map[string]struct{}, len(chats))
+		for _, c := range chats {
+			r.chats[strings.ToLower(strings.TrimPrefix(c, "@"))] = struct{}{}
+		}
+	}
+
+	return r
+}
+
+// allowChat reports whether the chat is in the allowlist, by ID or username.
+func (r *chatRules) allowChat(peer tg.PeerClass, username string) bool {
+	if r.chats == nil {
+		return true
+	}
+
+	var ids []string
+	switch p := peer.(type) {
+	case *tg.PeerUser:
+		ids = []string{strconv.FormatInt(p.UserID, 10)}
+	case *tg.PeerChat:
+		id := strconv.FormatInt(p.ChatID, 10)
+		ids = []string{id, "-" + id}
+	case *tg.PeerChannel:
+		id := strconv.FormatInt(p.ChannelID, 10)
+		ids = []string{id, "-100" + id}
+	}
+
+	if username != "" {
+		ids = append(ids, strings.ToLower(username))
+	}
+
+	for _, id := range ids {
+		if _, ok := r.chats[id]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mentioned reports whether the message mentions the account or replies to it,
+// Telegram sets the mentioned flag for both. Private messages are addressed to
+// the account anyway.
+func mentioned(msg *tg.Message, self *tg.User) bool {
+	if _, ok := msg.PeerID.(*tg.PeerUser); ok || msg.Mentioned {
+		return true
+	}
+
+	for _, e := range msg.Entities {
+		if m, ok := e.(*tg.MessageEntityMentionName); ok && m.UserID == self.ID {
+			return true
+		}
+	}
+
+	return self.Username != "" && strings.Contains(strings.ToLower(msg.Message), "@"+strings.ToLower(self.Username))
+}
+
+// setMuted remembers notify settings of the chat.
+func (r *chatRules) setMuted(peer tg.PeerClass, settings tg.PeerNotifySettings) {
+	key := peerKey(peer)
+	if key == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if until, ok := settings.GetMuteUntil(); ok && until > 0 {
+		r.muted[key] = time.Unix(int64(until), 0)
+
+		return
+	}
+
+	delete(r.muted, key)
+}
+
+func (r *chatRules) isMuted(peer tg.PeerClass, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.muted[peerKey(peer)]
+
+	return ok && now.Before(until)
+}
+
+// onNotifySettings follows changes of mute settings of chats, global settings
+// for all chats of a type are not taken into account.
+// See: https://core.telegram.org/constructor/updateNotifySettings
+func (t *Telegram) onNotifySettings(ctx context.Context, e tg.Entities, update *tg.UpdateNotifySettings) error {
+	if p, ok := update.Peer.(*tg.NotifyPeer); ok {
+		t.rules.setMuted(p.Peer, update.NotifySettings)
+	}
+
+	return nil
+}
+
+// accept applies chat rules to the message.
+func (t *Telegram) accept(msg *tg.Message, chatUsername string) bool {
+	if !t.rules.allowChat(msg.PeerID, chatUsername) {
+		t.log.Debug("skip message of not allowed chat", wlog.Any("peer", msg.PeerID))
+
+		return false
+	}
+
+	if t.rules.ignoreMuted && t.rules.isMuted(msg.PeerID, time.Now()) {
+		t.log.Debug("skip message of muted chat", wlog.Any("peer", msg.PeerID))
+
+		return false
+	}
+
+	if t.rules.mentionsOnly && !mentioned(msg, t.self) {
+		return false
+	}
+
+	return true
+}
+
+func peerKey(peer tg.PeerClass) string {
+	switch p := peer.(type) {
+	case *tg.PeerUser:
+		return "user:" + strconv.FormatInt(p.UserID, 10)
+	case *tg.PeerChat:
+		return "chat:" + strconv.FormatInt(p.ChatID, 10)
+	case *tg.PeerChannel:
+		return "channel:" + strconv.FormatInt(p.ChannelID, 10)
+	}
+
+	return ""
+}
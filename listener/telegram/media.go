@@ -0,0 +1,189 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gotd/td/telegram/downloader"
+	"github.com/gotd/td/tg"
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// downloadTimeout limits download of the attachment, the alert is pushed without
+// the file if it is exceeded.
+const downloadTimeout = 2 * time.Minute
+
+// media describes the media of the message, location is nil if the media has
+// no file to download.
+type media struct {
+	attachment *model.Attachment
+	location   tg.InputFileLocationClass
+}
+
+// describeMedia returns the media of the message, false if the message has none
+// or the media is not supported.
+func describeMedia(msg *tg.Message) (*media, bool) {
+	switch m := msg.Media.(type) {
+	case *tg.MessageMediaPhoto:
+		photo, ok := m.Photo.(*tg.Photo)
+		if !ok {
+			return nil, false
+		}
+
+		// Download the largest size of the photo.
+		var (
+			thumb string
+			size  int
+		)
+		for _, s := range photo.Sizes {
+			switch s := s.(type) {
+			case *tg.PhotoSize:
+				if s.Size > size {
+					thumb, size = s.Type, s.Size
+				}
+			case *tg.PhotoSizeProgressive:
+				if n := len(s.Sizes); n > 0 && s.Sizes[n-1] > size {
+					thumb, size = s.Type, s.Sizes[n-1]
+				}
+			}
+		}
+
+		md := &media{attachment: &model.Attachment{
+			Type:     model.AttachmentPhoto,
+			Name:     fmt.Sprintf("photo_%d.jpg", photo.ID),
+			MimeType: "image/jpeg",
+			Size:     int64(size),
+		}}
+		if thumb != "" {
+			md.location = &tg.InputPhotoFileLocation{
+				ID:            photo.ID,
+				AccessHash:    photo.AccessHash,
+				FileReference: photo.FileReference,
+				ThumbSize:     thumb,
+			}
+		}
+
+		return md, true
+	case *tg.MessageMediaDocument:
+		doc, ok := m.Document.(*tg.Document)
+		if !ok {
+			return nil, false
+		}
+
+		return &media{
+			attachment: document(doc),
+			location: &tg.InputDocumentFileLocation{
+				ID:            doc.ID,
+				AccessHash:    doc.AccessHash,
+				FileReference: doc.FileReference,
+			},
+		}, true
+	case *tg.MessageMediaGeo:
+		return location(m.Geo, "")
+	case *tg.MessageMediaGeoLive:
+		return location(m.Geo, "")
+	case *tg.MessageMediaVenue:
+		return location(m.Geo, m.Title)
+	case *tg.MessageMediaContact:
+		name := strings.TrimSpace(m.FirstName + " " + m.LastName)
+		if m.PhoneNumber != "" {
+			name = strings.TrimSpace(name + " " + m.PhoneNumber)
+		}
+
+		return &media{attachment: &model.Attachment{Type: model.AttachmentContact, Name: name}}, true
+	case *tg.MessageMediaPoll:
+		return &media{attachment: &model.Attachment{Type: model.AttachmentPoll, Name: m.Poll.Question.Text}}, true
+	}
+
+	return nil, false
+}
+
+// document describes the document by its attributes: voice messages, audio and
+// video have duration, stickers are named by their emoji.
+func document(doc *tg.Document) *model.Attachment {
+	a := &model.Attachment{
+		Type:     model.AttachmentDocument,
+		MimeType: doc.MimeType,
+		Size:     doc.Size,
+	}
+
+	var emoji string
+	for _, attr := range doc.Attributes {
+		switch attr := attr.(type) {
+		case *tg.DocumentAttributeFilename:
+			a.Name = attr.FileName
+		case *tg.DocumentAttributeAudio:
+			a.Type = model.AttachmentAudio
+			if attr.Voice {
+				a.Type = model.AttachmentVoice
+			}
+
+			a.Duration = time.Duration(attr.Duration) * time.Second
+		case *tg.DocumentAttributeVideo:
+			a.Type = model.AttachmentVideo
+			a.Duration = time.Duration(attr.Duration * float64(time.Second))
+		case *tg.DocumentAttributeSticker:
+			a.Type = model.AttachmentSticker
+			emoji = attr.Alt
+		}
+	}
+
+	// Stickers have file names, but emoji describe them better.
+	if a.Type == model.AttachmentSticker && emoji != "" {
+		a.Name = emoji
+	}
+
+	return a
+}
+
+func location(geo tg.GeoPointClass, title string) (*media, bool) {
+	point, ok := geo.(*tg.GeoPoint)
+	if !ok {
+		return nil, false
+	}
+
+	name := fmt.Sprintf("%.6f,%.6f", point.Lat, point.Long)
+	if title != "" {
+		name = title + " " + name
+	}
+
+	return &media{attachment: &model.Attachment{Type: model.AttachmentLocation, Name: name}}, true
+}
+
+// attach adds description of the media to the alert text, the text is the
+// description alone if the message has no caption.
+func attach(alert *model.Alert, md *media) {
+	alert.Attachments = append(alert.Attachments, md.attachment)
+	if alert.Text == "" {
+		alert.Text = md.attachment.String()
+
+		return
+	}
+
+	alert.Text += "\n" + md.attachment.String()
+}
+
+// download saves the file of the media to the attachment store, files larger
+// than the maximum size of the store are only described.
+func (t *Telegram) download(ctx context.Context, md *media) {
+	a := md.attachment
+	if a.Size > t.store.MaxSize() {
+		t.log.Debug("skip large attachment", wlog.String("name", a.Name), wlog.Any("size", a.Size))
+
+		return
+	}
+
+	err := t.store.Save(a, func(w io.Writer) error {
+		_, err := downloader.NewDownloader().Download(t.cli.API(), md.location).Stream(ctx, w)
+
+		return err
+	})
+	if err != nil {
+		t.log.Warn("download attachment", wlog.Err(err), wlog.String("type", a.Type), wlog.String("name", a.Name))
+	}
+}
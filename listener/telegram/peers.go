@@ -0,0 +1,157 @@
+package telegram
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tg"
+)
+
+const peersFile = "peers.json"
+
+// peersSaveInterval is how often changed peers are written to the file, they
+// are also saved on close.
+const peersSaveInterval = time.Minute
+
+// peer is the cached name of a user, a chat or a channel.
+type peer struct {
+	Name     string `json:"name"`
+	Username string `json:"username,omitempty"`
+}
+
+// peerCache remembers names of peers seen in updates, since updates may come
+// without entities, e.g. short updates or messages of min peers. It is persisted
+// in the session directory.
+type peerCache struct {
+	path string
+
+	mu       sync.Mutex
+	dirty    bool
+	Users    map[int64]*peer `json:"users"`
+	Chats    map[int64]*peer `json:"chats"`
+	Channels map[int64]*peer `json:"channels"`
+}
+
+// loadPeerCache reads the cache from the file, an empty cache is returned if
+// there is no file yet.
+func loadPeerCache(path string) (*peerCache, error) {
+	c := &peerCache{
+		path:     path,
+		Users:    make(map[int64]*peer),
+		Chats:    make(map[int64]*peer),
+		Channels: make(map[int64]*peer),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// update caches peers of the entities.
+func (c *peerCache) update(users map[int64]*tg.User, chats map[int64]*tg.Chat, channels map[int64]*tg.Channel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, u := range users {
+		// Min users have no username nor full name, cached peer is kept.
+		if u.Min && c.Users[id] != nil {
+			continue
+		}
+
+		c.put(c.Users, id, &peer{Name: userName(u), Username: u.Username})
+	}
+
+	for id, ch := range chats {
+		c.put(c.Chats, id, &peer{Name: ch.Title})
+	}
+
+	for id, ch := range channels {
+		if ch.Min && c.Channels[id] != nil {
+			continue
+		}
+
+		c.put(c.Channels, id, &peer{Name: ch.Title, Username: ch.Username})
+	}
+}
+
+func (c *peerCache) put(peers map[int64]*peer, id int64, p *peer) {
+	if cached, ok := peers[id]; ok && *cached == *p {
+		return
+	}
+
+	peers[id] = p
+	c.dirty = true
+}
+
+func (c *peerCache) user(id int64) (*peer, bool) {
+	return c.find(c.Users, id)
+}
+
+func (c *peerCache) chat(id int64) (*peer, bool) {
+	return c.find(c.Chats, id)
+}
+
+func (c *peerCache) channel(id int64) (*peer, bool) {
+	return c.find(c.Channels, id)
+}
+
+func (c *peerCache) find(peers map[int64]*peer, id int64) (*peer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := peers[id]
+
+	return p, ok
+}
+
+// save writes the cache to the file if it was changed.
+func (c *peerCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+
+	c.dirty = false
+
+	return nil
+}
+
+func userName(u *tg.User) string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" && u.Username != "" {
+		return "@" + u.Username
+	}
+
+	return name
+}
@@ -0,0 +1,119 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/telegram/auth/qrlogin"
+	"github.com/gotd/td/tgerr"
+	"github.com/webitel/wlog"
+	"golang.org/x/term"
+	"rsc.io/qr"
+
+	"github.com/kirychukyurii/notificator/model"
+	"github.com/kirychukyurii/notificator/notifier"
+)
+
+// qrLogin logs in by QR code scanned in the Telegram app of the account, the
+// code is refreshed when it expires. 2FA password is requested from the
+// authenticator if the account has it.
+func (t *Telegram) qrLogin(ctx context.Context, client *telegram.Client, loggedIn qrlogin.LoggedIn, a auth.UserAuthenticator) error {
+	status, err := client.Auth().Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if status.Authorized {
+		return nil
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.LoginTimeout)
+	defer cancel()
+
+	show := func(ctx context.Context, token qrlogin.Token) error {
+		code, err := qr.Encode(token.URL(), qr.M)
+		if err != nil {
+			return err
+		}
+
+		t.log.Info("scan QR code to log in", wlog.String("expires", token.Expires().Format("15:04:05")))
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			fmt.Println(terminalQR(code))
+		}
+
+		t.queue.Push(&notifier.Message{
+			Channel: "telegram",
+			Content: &model.AuthQR{
+				ID:      id,
+				Account: t.cfg.Phone,
+				PNG:     code.PNG(),
+				Expires: token.Expires(),
+			},
+		})
+
+		return nil
+	}
+
+	_, err = client.QR().Auth(ctx, loggedIn, show)
+	if tgerr.Is(err, "SESSION_PASSWORD_NEEDED") {
+		t.qrStatus(id, "QR code accepted, 2FA password required", false)
+
+		var password string
+		if password, err = a.Password(ctx); err == nil {
+			_, err = client.Auth().Password(ctx, password)
+		}
+	}
+
+	if err != nil {
+		t.qrStatus(id, "failed: "+err.Error(), true)
+
+		return err
+	}
+
+	t.qrStatus(id, "logged in", true)
+
+	return nil
+}
+
+func (t *Telegram) qrStatus(id, text string, done bool) {
+	t.queue.Push(&notifier.Message{
+		Channel: "telegram",
+		Content: &model.AuthStatus{ID: id, Text: text, Done: done},
+	})
+}
+
+// terminalQR renders the code with half blocks, two rows of modules per line,
+// light modules are drawn for dark terminals.
+func terminalQR(code *qr.Code) string {
+	const quiet = 2
+
+	var sb strings.Builder
+	for y := -quiet; y < code.Size+quiet; y += 2 {
+		for x := -quiet; x < code.Size+quiet; x++ {
+			top, bottom := !code.Black(x, y), !code.Black(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteRune('█')
+			case top:
+				sb.WriteRune('▀')
+			case bottom:
+				sb.WriteRune('▄')
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
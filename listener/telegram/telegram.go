@@ -6,33 +6,49 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync/atomic"
+	"time"
 
 	"github.com/gotd/td/telegram"
 	"github.com/gotd/td/telegram/auth"
+	"github.com/gotd/td/telegram/auth/qrlogin"
+	"github.com/gotd/td/telegram/query"
+	"github.com/gotd/td/telegram/query/dialogs"
 	"github.com/gotd/td/telegram/updates"
 	"github.com/gotd/td/telegram/updates/hook"
 	"github.com/gotd/td/tg"
 	"github.com/webitel/wlog"
+	"golang.org/x/term"
 
+	"github.com/kirychukyurii/notificator/attachment"
 	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/listener/filter"
+	"github.com/kirychukyurii/notificator/listener/severity"
 	"github.com/kirychukyurii/notificator/model"
 	"github.com/kirychukyurii/notificator/notifier"
+	"github.com/kirychukyurii/notificator/server"
 )
 
 type Telegram struct {
-	log   *wlog.Logger
-	cfg   *listeners.TelegramConfig
-	queue *notifier.Queue
-	cli   *telegram.Client
-	gaps  *updates.Manager
+	log      *wlog.Logger
+	cfg      *listeners.TelegramConfig
+	queue    *notifier.Queue
+	filter   *filter.Filter
+	severity *severity.Classifier
+	peers    *peerCache
+	rules    *chatRules
+	store    *attachment.Store
+	self     *tg.User
+	cli      *telegram.Client
+	gaps     *updates.Manager
 
 	listen *atomic.Bool
 
 	stopFunc stopFunc
 }
 
-func New(cfg *listeners.TelegramConfig, sessionDir string, log *wlog.Logger, queue *notifier.Queue) (*Telegram, error) {
+func New(cfg *listeners.TelegramConfig, sessionDir string, log *wlog.Logger, queue *notifier.Queue, srv *server.Server, store *attachment.Store) (*Telegram, error) {
 	// Setting up session storage.
 	// This is needed to reuse session and not login every time.
 	dir := filepath.Join(sessionDir, sessionFolder(cfg.Phone))
@@ -45,13 +61,63 @@ func New(cfg *listeners.TelegramConfig, sessionDir string, log *wlog.Logger, que
 		Path: filepath.Join(dir, "session.json"),
 	}
 
-	// Dispatcher is used to register handlers for events.
-	dispatcher := tg.NewUpdateDispatcher()
+	f, err := filter.New(cfg.Filter, log)
+	if err != nil {
+		return nil, fmt.Errorf("filter: %v", err)
+	}
+
+	classifier, err := severity.New(cfg.Severity)
+	if err != nil {
+		return nil, fmt.Errorf("severity: %v", err)
+	}
+
+	if cfg.Login != listeners.TelegramLoginTerminal && cfg.LoginTimeout <= 0 {
+		return nil, fmt.Errorf("login timeout must be positive")
+	}
+
+	var authenticator auth.UserAuthenticator = Auth{phone: cfg.Phone}
+	switch cfg.Login {
+	case listeners.TelegramLoginRemote:
+		authenticator = newRemoteAuth(cfg.Phone, log, queue, srv, cfg.LoginTimeout)
+	case listeners.TelegramLoginQR:
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			authenticator = newRemoteAuth(cfg.Phone, log, queue, srv, cfg.LoginTimeout)
+		}
+	case listeners.TelegramLoginTerminal, "":
+	default:
+		return nil, fmt.Errorf("unknown login %q", cfg.Login)
+	}
+
+	if cfg.DownloadAttachments && store == nil {
+		return nil, fmt.Errorf("download attachments: attachment store is not configured")
+	}
 
-	listen := &atomic.Bool{}
+	peers, err := loadPeerCache(filepath.Join(dir, peersFile))
+	if err != nil {
+		return nil, fmt.Errorf("load peers: %v", err)
+	}
 
-	dispatcher.OnNewMessage(onNewMessage(listen, queue))
-	dispatcher.OnNewChannelMessage(onNewChannelMessage(listen, queue))
+	t := &Telegram{
+		log:      log,
+		cfg:      cfg,
+		queue:    queue,
+		filter:   f,
+		severity: classifier,
+		peers:    peers,
+		rules:    newChatRules(cfg.Chats, cfg.MentionsOnly, cfg.IgnoreMuted),
+		listen:   &atomic.Bool{},
+	}
+
+	if cfg.DownloadAttachments {
+		t.store = store
+	}
+
+	// Dispatcher is used to register handlers for events.
+	dispatcher := tg.NewUpdateDispatcher()
+	dispatcher.OnNewMessage(t.onNewMessage)
+	dispatcher.OnNewChannelMessage(t.onNewChannelMessage)
+	dispatcher.OnNotifySettings(t.onNotifySettings)
+	loggedIn := qrlogin.OnLoginToken(dispatcher)
 	gaps := updates.New(updates.Config{
 		Handler: dispatcher,
 	})
@@ -70,9 +136,21 @@ func New(cfg *listeners.TelegramConfig, sessionDir string, log *wlog.Logger, que
 		return nil, err
 	}
 
-	// Authentication flow handles authentication process, like prompting for code and 2FA password.
-	flow := auth.NewFlow(Auth{phone: cfg.Phone}, auth.SendCodeOptions{})
-	if err := client.Auth().IfNecessary(context.Background(), flow); err != nil {
+	if cfg.Login == listeners.TelegramLoginQR {
+		err = t.qrLogin(context.Background(), client, loggedIn, authenticator)
+	} else {
+		// Authentication flow handles authentication process, like prompting for code and 2FA password.
+		flow := auth.NewFlow(authenticator, auth.SendCodeOptions{})
+		err = client.Auth().IfNecessary(context.Background(), flow)
+	}
+
+	if remote, ok := authenticator.(*remoteAuth); ok {
+		remote.finish(err)
+	}
+
+	if err != nil {
+		_ = stop()
+
 		return nil, err
 	}
 
@@ -84,15 +162,12 @@ func New(cfg *listeners.TelegramConfig, sessionDir string, log *wlog.Logger, que
 	log.Info("logged user", wlog.String("first_name", self.FirstName), wlog.String("last_name", self.LastName),
 		wlog.String("username", self.Username), wlog.Int64("id", self.ID))
 
-	return &Telegram{
-		log:      log,
-		cfg:      cfg,
-		queue:    queue,
-		cli:      client,
-		gaps:     gaps,
-		listen:   listen,
-		stopFunc: stop,
-	}, nil
+	t.self = self
+	t.cli = client
+	t.gaps = gaps
+	t.stopFunc = stop
+
+	return t, nil
 }
 
 func (t *Telegram) Listen(ctx context.Context) error {
@@ -112,6 +187,14 @@ func (t *Telegram) Listen(ctx context.Context) error {
 		},
 	}
 
+	if t.cfg.FillPeersOnStart || t.cfg.IgnoreMuted {
+		if err := t.loadDialogs(ctx); err != nil {
+			t.log.Warn("load telegram dialogs", wlog.Err(err))
+		}
+	}
+
+	go t.savePeers(ctx)
+
 	t.listen.Store(true)
 	defer t.listen.Store(false)
 	if err := t.gaps.Run(ctx, t.cli.API(), status.User.ID, opts); err != nil {
@@ -125,7 +208,33 @@ func (t *Telegram) String() string {
 	return "telegram"
 }
 
+// savePeers writes changed peers periodically, so updates are not slowed down
+// by writing the file on each message.
+func (t *Telegram) savePeers(ctx context.Context) {
+	ticker := time.NewTicker(peersSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.peers.save(); err != nil {
+				t.log.Warn("save telegram peers", wlog.Err(err))
+			}
+		}
+	}
+}
+
 func (t *Telegram) Close() error {
+	if dropped := t.filter.Dropped(); len(dropped) > 0 {
+		t.log.Info("messages dropped by filter", wlog.Any("dropped", dropped))
+	}
+
+	if err := t.peers.save(); err != nil {
+		t.log.Warn("save telegram peers", wlog.Err(err))
+	}
+
 	if t.stopFunc != nil {
 		return t.stopFunc()
 	}
@@ -189,68 +298,162 @@ func sessionFolder(phone string) string {
 
 // onNewMessage handles new private messages or messages in a basic group.
 // See: https://core.telegram.org/constructor/updateNewMessage
-func onNewMessage(listen *atomic.Bool, queue *notifier.Queue) tg.NewMessageHandler {
-	return func(ctx context.Context, e tg.Entities, update *tg.UpdateNewMessage) error {
-		if !listen.Load() {
-			return nil
-		}
+func (t *Telegram) onNewMessage(ctx context.Context, e tg.Entities, update *tg.UpdateNewMessage) error {
+	t.handleMessage(e, update.Message)
 
-		msg, ok := update.Message.(*tg.Message)
-		if !ok {
-			return nil
-		}
+	return nil
+}
 
-		if msg.Out {
-			// Outgoing message.
-			return nil
-		}
+// onNewChannelMessage handles new messages in channel/supergroup.
+// See: https://core.telegram.org/constructor/updateNewChannelMessage
+func (t *Telegram) onNewChannelMessage(ctx context.Context, e tg.Entities, update *tg.UpdateNewChannelMessage) error {
+	t.handleMessage(e, update.Message)
 
-		if _, ok := msg.GetViaBotID(); ok {
-			return nil
-		}
+	return nil
+}
 
-		queue.Push(&notifier.Message{
-			Channel: "telegram",
-			Content: &model.Alert{
-				Channel: "telegram",
-				Text:    msg.Message,
-			},
-		})
+func (t *Telegram) handleMessage(e tg.Entities, m tg.MessageClass) {
+	if !t.listen.Load() {
+		return
+	}
 
-		return nil
+	msg, ok := m.(*tg.Message)
+	if !ok {
+		return
+	}
+
+	if msg.Out {
+		// Outgoing message.
+		return
+	}
+
+	if _, ok := msg.GetViaBotID(); ok {
+		return
 	}
+
+	t.peers.update(e.Users, e.Chats, e.Channels)
+
+	alert := t.alert(msg)
+	md, hasMedia := describeMedia(msg)
+	if hasMedia {
+		attach(alert, md)
+	}
+
+	if !t.accept(msg, alert.Labels["chat_username"]) {
+		return
+	}
+
+	if !t.filter.Allow(alert) {
+		return
+	}
+
+	t.severity.Apply(alert)
+	if hasMedia && t.store != nil && md.location != nil {
+		// Download blocks other updates, so the alert is pushed once the file is stored.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+			defer cancel()
+
+			t.download(ctx, md)
+			t.push(alert)
+		}()
+
+		return
+	}
+
+	t.push(alert)
 }
 
-// onNewMessage handles new messages in channel/supergroup.
-// See: https://core.telegram.org/constructor/updateNewChannelMessage
-func onNewChannelMessage(listen *atomic.Bool, queue *notifier.Queue) tg.NewChannelMessageHandler {
-	return func(ctx context.Context, e tg.Entities, update *tg.UpdateNewChannelMessage) error {
-		if !listen.Load() {
-			return nil
-		}
+func (t *Telegram) push(alert *model.Alert) {
+	t.queue.Push(&notifier.Message{
+		Channel: alert.Channel,
+		Content: alert,
+	})
+}
 
-		msg, ok := update.Message.(*tg.Message)
-		if !ok {
-			return nil
+// alert builds the alert from the message, names of the sender and the chat
+// are resolved from the peer cache.
+func (t *Telegram) alert(msg *tg.Message) *model.Alert {
+	alert := &model.Alert{
+		Channel:   "telegram",
+		Text:      msg.Message,
+		MessageID: strconv.Itoa(msg.ID),
+		Labels:    make(map[string]string),
+	}
+
+	switch p := msg.PeerID.(type) {
+	case *tg.PeerUser:
+		// Private dialog, the peer is the sender.
+		alert.Labels["chat_id"] = strconv.FormatInt(p.UserID, 10)
+		if u, ok := t.peers.user(p.UserID); ok {
+			alert.Chat = u.Name
+			if u.Username != "" {
+				alert.Labels["chat_username"] = u.Username
+			}
+		}
+	case *tg.PeerChat:
+		alert.Labels["chat_id"] = strconv.FormatInt(p.ChatID, 10)
+		if c, ok := t.peers.chat(p.ChatID); ok {
+			alert.Chat = c.Name
 		}
+	case *tg.PeerChannel:
+		alert.Labels["chat_id"] = strconv.FormatInt(p.ChannelID, 10)
+		alert.Link = fmt.Sprintf("https://t.me/c/%d/%d", p.ChannelID, msg.ID)
+		if c, ok := t.peers.channel(p.ChannelID); ok {
+			alert.Chat = c.Name
+			if c.Username != "" {
+				alert.Labels["chat_username"] = c.Username
+				alert.Link = fmt.Sprintf("https://t.me/%s/%d", c.Username, msg.ID)
+			}
+		}
+	}
 
-		if msg.Out {
-			// Outgoing message.
-			return nil
+	from, ok := msg.GetFromID()
+	if !ok {
+		// Private dialogs and channel posts have no sender, the peer is.
+		from = msg.PeerID
+	}
+
+	switch p := from.(type) {
+	case *tg.PeerUser:
+		alert.Labels["user_id"] = strconv.FormatInt(p.UserID, 10)
+		if u, ok := t.peers.user(p.UserID); ok {
+			alert.From = u.Name
+			if u.Username != "" {
+				alert.Labels["username"] = u.Username
+			}
 		}
+	case *tg.PeerChannel:
+		// Anonymous admins and channels post on behalf of the channel.
+		if c, ok := t.peers.channel(p.ChannelID); ok {
+			alert.From = c.Name
+		}
+	}
+
+	if author, ok := msg.GetPostAuthor(); ok && author != "" {
+		alert.From = author
+	}
+
+	return alert
+}
 
-		if _, ok := msg.GetViaBotID(); ok {
-			return nil
+// loadDialogs caches peers of all dialogs, so names are resolved for updates
+// which come without entities, and remembers mute settings of dialogs.
+func (t *Telegram) loadDialogs(ctx context.Context) error {
+	err := query.GetDialogs(t.cli.API()).BatchSize(100).ForEach(ctx, func(ctx context.Context, elem dialogs.Elem) error {
+		if t.cfg.FillPeersOnStart {
+			t.peers.update(elem.Entities.Users(), elem.Entities.Chats(), elem.Entities.Channels())
 		}
 
-		queue.Push(&notifier.Message{
-			Channel: "telegram",
-			Content: &model.Alert{
-				Channel: "telegram",
-				Text:    msg.Message,
-			},
-		})
+		if d, ok := elem.Dialog.(*tg.Dialog); ok {
+			t.rules.setMuted(d.Peer, d.NotifySettings)
+		}
 
 		return nil
+	})
+	if err != nil {
+		return err
 	}
+
+	return t.peers.save()
 }
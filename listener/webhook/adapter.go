@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// maxBodySize limits size of webhook request body.
+const maxBodySize = 1 << 20
+
+// Adapter decodes alerts from webhook requests of a particular tool.
+type Adapter interface {
+	Alerts(r *http.Request) ([]*model.Alert, error)
+}
+
+// AdapterFunc creates adapter for the webhook.
+type AdapterFunc func(cfg *listeners.WebhookConfig, log *wlog.Logger) (Adapter, error)
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = map[string]AdapterFunc{
+		listeners.WebhookFormatGeneric:      newGenericAdapter,
+		listeners.WebhookFormatAlertmanager: newAlertmanagerAdapter,
+		listeners.WebhookFormatGrafana:      newGrafanaAdapter,
+		listeners.WebhookFormatZabbix:       newZabbixAdapter,
+		listeners.WebhookFormatJira:         newJiraAdapter,
+	}
+)
+
+// RegisterAdapter makes adapter available for webhooks with the format,
+// built-in adapter of the same format is replaced.
+func RegisterAdapter(format string, f AdapterFunc) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+
+	adapters[format] = f
+}
+
+func newAdapter(cfg *listeners.WebhookConfig, log *wlog.Logger) (Adapter, error) {
+	adaptersMu.RLock()
+	f, ok := adapters[cfg.Format]
+	adaptersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", cfg.Format)
+	}
+
+	return f(cfg, log)
+}
+
+// parseSeverity parses severity reported by the tool, empty severity is returned
+// if it is unknown, so rules of the listener are applied.
+func parseSeverity(log *wlog.Logger, v string) model.Severity {
+	if v == "" {
+		return ""
+	}
+
+	s, err := model.ParseSeverity(v)
+	if err != nil {
+		log.Warn("skip alert severity", wlog.Err(err))
+	}
+
+	return s
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// fixture returns the sample payload from testdata.
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+// adapterAlerts decodes alerts from the body by the adapter of the webhook format.
+func adapterAlerts(t *testing.T, cfg *listeners.WebhookConfig, body string) ([]*model.Alert, error) {
+	t.Helper()
+
+	if cfg.Name == "" {
+		cfg.Name = "test"
+	}
+
+	adapter, err := newAdapter(cfg, wlog.NewLogger(&wlog.LoggerConfiguration{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/webhook/"+cfg.Name, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	return adapter.Alerts(r)
+}
+
+func TestNewAdapterUnknownFormat(t *testing.T) {
+	cfg := &listeners.WebhookConfig{Name: "test", Format: "nagios"}
+	if _, err := newAdapter(cfg, wlog.NewLogger(&wlog.LoggerConfiguration{})); err == nil {
+		t.Fatal("adapter of unknown format created")
+	}
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// alertmanagerVersion is the supported version of Alertmanager webhook payload.
+const alertmanagerVersion = "4"
+
+// alertmanagerMessage is the payload Prometheus Alertmanager sends to webhook receivers,
+// see https://prometheus.io/docs/alerting/latest/configuration/#webhook_config.
+type alertmanagerMessage struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	TruncatedAlerts   int                 `json:"truncatedAlerts"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []alertmanagerAlert `json:"alerts"`
+}
+
+type alertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// alertmanagerAdapter reads alerts from Prometheus Alertmanager webhook payload,
+// labels of alerts are preserved for routing.
+type alertmanagerAdapter struct {
+	cfg *listeners.WebhookConfig
+	log *wlog.Logger
+}
+
+func newAlertmanagerAdapter(cfg *listeners.WebhookConfig, log *wlog.Logger) (Adapter, error) {
+	return &alertmanagerAdapter{cfg: cfg, log: log}, nil
+}
+
+func (a *alertmanagerAdapter) Alerts(r *http.Request) ([]*model.Alert, error) {
+	var msg alertmanagerMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("decode alertmanager payload: %v", err)
+	}
+
+	if msg.Version != alertmanagerVersion {
+		return nil, fmt.Errorf("unsupported alertmanager payload version %q", msg.Version)
+	}
+
+	if msg.TruncatedAlerts > 0 {
+		a.log.Warn("alertmanager truncated alerts", wlog.Int("truncated", msg.TruncatedAlerts), wlog.String("group", msg.GroupKey))
+	}
+
+	alerts := make([]*model.Alert, 0, len(msg.Alerts))
+	for _, am := range msg.Alerts {
+		alerts = append(alerts, a.alert(&am, msg.Receiver))
+	}
+
+	return alerts, nil
+}
+
+func (a *alertmanagerAdapter) alert(am *alertmanagerAlert, receiver string) *model.Alert {
+	return &model.Alert{
+		Channel:     a.cfg.Name,
+		Text:        first(am.Annotations["summary"], am.Annotations["description"], am.Annotations["message"], am.Labels["alertname"]),
+		From:        first(am.Labels["instance"], am.Labels["job"], receiver),
+		Chat:        am.Labels["alertname"],
+		Severity:    parseSeverity(a.log, am.Labels["severity"]),
+		Status:      model.ParseStatus(am.Status),
+		Fingerprint: am.Fingerprint,
+		Labels:      am.Labels,
+		Annotations: am.Annotations,
+		StartsAt:    am.StartsAt,
+		EndsAt:      am.EndsAt,
+	}
+}
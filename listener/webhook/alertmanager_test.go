@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+func TestAlertmanagerAdapter(t *testing.T) {
+	cfg := &listeners.WebhookConfig{Format: listeners.WebhookFormatAlertmanager}
+
+	t.Run("firing", func(t *testing.T) {
+		alerts, err := adapterAlerts(t, cfg, fixture(t, "alertmanager_firing.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(alerts) != 2 {
+			t.Fatalf("decoded %d alerts, want 2", len(alerts))
+		}
+
+		a := alerts[0]
+		want := model.Alert{
+			Channel:     "test",
+			Text:        "Instance db-1.example.com:9100 down",
+			From:        "db-1.example.com:9100",
+			Chat:        "InstanceDown",
+			Severity:    model.SeverityCritical,
+			Status:      model.StatusFiring,
+			Fingerprint: "d1b7e5f4c1a2b3c4",
+			StartsAt:    time.Date(2024, 5, 14, 2, 11, 30, 0, time.UTC),
+		}
+
+		if a.Channel != want.Channel || a.Text != want.Text || a.From != want.From || a.Chat != want.Chat ||
+			a.Severity != want.Severity || a.Status != want.Status || a.Fingerprint != want.Fingerprint {
+			t.Errorf("alert %+v, want %+v", a, want)
+		}
+
+		if !a.StartsAt.Equal(want.StartsAt) || !a.EndsAt.IsZero() {
+			t.Errorf("alert starts at %s, ends at %s, want %s and zero", a.StartsAt, a.EndsAt, want.StartsAt)
+		}
+
+		// Labels are preserved for routing.
+		labels := map[string]string{
+			"alertname": "InstanceDown",
+			"instance":  "db-1.example.com:9100",
+			"job":       "node",
+			"severity":  "critical",
+		}
+
+		if !maps.Equal(a.Labels, labels) {
+			t.Errorf("alert labels %v, want %v", a.Labels, labels)
+		}
+
+		// Text falls back to the description without summary.
+		if got, want := alerts[1].Text, "db-2.example.com:9100 of job node has been down for more than 5 minutes."; got != want {
+			t.Errorf("alert text %q, want %q", got, want)
+		}
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		alerts, err := adapterAlerts(t, cfg, fixture(t, "alertmanager_resolved.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(alerts) != 1 {
+			t.Fatalf("decoded %d alerts, want 1", len(alerts))
+		}
+
+		a := alerts[0]
+		if a.Status != model.StatusResolved || a.Fingerprint != "d1b7e5f4c1a2b3c4" {
+			t.Errorf("alert status %s, fingerprint %s, want resolved d1b7e5f4c1a2b3c4", a.Status, a.Fingerprint)
+		}
+
+		if want := time.Date(2024, 5, 14, 2, 31, 30, 0, time.UTC); !a.EndsAt.Equal(want) {
+			t.Errorf("alert ends at %s, want %s", a.EndsAt, want)
+		}
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		if _, err := adapterAlerts(t, cfg, `{"version":"3","alerts":[]}`); err == nil {
+			t.Fatal("payload of version 3 decoded")
+		}
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		if _, err := adapterAlerts(t, cfg, `{"version":`); err == nil {
+			t.Fatal("invalid payload decoded")
+		}
+	})
+}
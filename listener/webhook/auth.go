@@ -0,0 +1,256 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+)
+
+var (
+	errUnauthorized = errors.New("webhook unauthorized")
+	errForbidden    = errors.New("webhook forbidden")
+)
+
+// signatureStyle describes where the signature and the signed timestamp are sent.
+type signatureStyle struct {
+	header          string
+	prefix          string
+	timestampHeader string
+
+	// signed returns signed data of the body and the timestamp, if sent.
+	signed func(timestamp string, body []byte) []byte
+}
+
+var signatureStyles = map[string]signatureStyle{
+	listeners.SignatureGitHub: {
+		header: "X-Hub-Signature-256",
+		prefix: "sha256=",
+		signed: func(_ string, body []byte) []byte { return body },
+	},
+	listeners.SignatureSlack: {
+		header:          "X-Slack-Signature",
+		prefix:          "v0=",
+		timestampHeader: "X-Slack-Request-Timestamp",
+		signed: func(ts string, body []byte) []byte {
+			return append([]byte("v0:"+ts+":"), body...)
+		},
+	},
+	listeners.SignatureGrafana: {
+		header:          "X-Grafana-Alerting-Signature",
+		timestampHeader: "X-Grafana-Alerting-Timestamp",
+		signed: func(ts string, body []byte) []byte {
+			if ts == "" {
+				return body
+			}
+
+			return append([]byte(ts+":"), body...)
+		},
+	},
+}
+
+// Auth authenticates webhook requests by signatures, credentials and client addresses.
+type Auth struct {
+	cfg       *listeners.WebhookAuth
+	style     signatureStyle
+	allowedIP []netip.Prefix
+}
+
+// NewAuth returns nil if no authentication is configured.
+func NewAuth(cfg *listeners.WebhookAuth) (*Auth, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	a := &Auth{cfg: cfg}
+	if s := cfg.Signature; s != nil {
+		style, ok := signatureStyles[s.Style]
+		if !ok {
+			return nil, fmt.Errorf("signature: unknown style %q", s.Style)
+		}
+
+		if s.Secret == "" {
+			return nil, fmt.Errorf("signature: secret required")
+		}
+
+		if s.Header != "" {
+			style.header = s.Header
+		}
+
+		a.style = style
+	}
+
+	for _, v := range cfg.AllowedIPs {
+		prefix, err := parsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("allowed ips: %v", err)
+		}
+
+		a.allowedIP = append(a.allowedIP, prefix)
+	}
+
+	return a, nil
+}
+
+// Authenticate checks the request, body of the request is read to verify the
+// signature and replaced to be read again.
+func (a *Auth) Authenticate(r *http.Request) error {
+	if a == nil {
+		return nil
+	}
+
+	if len(a.allowedIP) > 0 {
+		ip, err := a.clientIP(r)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errForbidden, err)
+		}
+
+		if !a.allowed(ip) {
+			return fmt.Errorf("%w: address %s not allowed", errForbidden, ip)
+		}
+	}
+
+	if token := a.cfg.BearerToken; token != "" {
+		v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !equal(v, token) {
+			return fmt.Errorf("%w: invalid bearer token", errUnauthorized)
+		}
+	}
+
+	if basic := a.cfg.BasicAuth; basic != nil {
+		user, password, ok := r.BasicAuth()
+		// Both are compared, so the time does not tell which one is wrong.
+		if !ok || !equal(user, basic.Username) || !equal(password, basic.Password) {
+			return fmt.Errorf("%w: invalid basic auth", errUnauthorized)
+		}
+	}
+
+	if a.cfg.Signature != nil {
+		if err := a.verifySignature(r); err != nil {
+			return fmt.Errorf("%w: %v", errUnauthorized, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Auth) verifySignature(r *http.Request) error {
+	signature, ok := strings.CutPrefix(r.Header.Get(a.style.header), a.style.prefix)
+	if !ok || signature == "" {
+		return fmt.Errorf("signature header %s missing", a.style.header)
+	}
+
+	var ts string
+	if a.style.timestampHeader != "" {
+		ts = r.Header.Get(a.style.timestampHeader)
+		if err := a.checkTimestamp(ts); err != nil {
+			return err
+		}
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(io.LimitReader(r.Body, maxBodySize)); err != nil {
+			return fmt.Errorf("read body: %v", err)
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("decode signature: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(a.cfg.Signature.Secret))
+	mac.Write(a.style.signed(ts, body))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return fmt.Errorf("signature mismatch")
+	}
+
+	return nil
+}
+
+// checkTimestamp rejects replayed requests, Slack requires the timestamp while
+// Grafana sends it only if configured.
+func (a *Auth) checkTimestamp(ts string) error {
+	if ts == "" {
+		if a.cfg.Signature.Style == listeners.SignatureGrafana {
+			return nil
+		}
+
+		return fmt.Errorf("timestamp header %s missing", a.style.timestampHeader)
+	}
+
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parse timestamp: %v", err)
+	}
+
+	if tolerance := a.cfg.Signature.Tolerance; tolerance > 0 {
+		if age := time.Since(time.Unix(sec, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("timestamp is out of tolerance %s", tolerance)
+		}
+	}
+
+	return nil
+}
+
+func (a *Auth) clientIP(r *http.Request) (netip.Addr, error) {
+	if a.cfg.TrustForwardedFor {
+		if v := r.Header.Values("X-Forwarded-For"); len(v) > 0 {
+			entries := strings.Split(v[len(v)-1], ",")
+
+			return netip.ParseAddr(strings.TrimSpace(entries[len(entries)-1]))
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return netip.ParseAddr(host)
+}
+
+func (a *Auth) allowed(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	for _, prefix := range a.allowedIP {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parsePrefix(v string) (netip.Prefix, error) {
+	if strings.Contains(v, "/") {
+		return netip.ParsePrefix(v)
+	}
+
+	addr, err := netip.ParseAddr(v)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// equal compares secrets in constant time.
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
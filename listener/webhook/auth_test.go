@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+)
+
+const (
+	testSecret = "secret"
+	testBody   = `{"status":"firing"}`
+)
+
+func sign(data string) string {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(data))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func signature(style string) *listeners.WebhookSignature {
+	return &listeners.WebhookSignature{Style: style, Secret: testSecret, Tolerance: 5 * time.Minute}
+}
+
+func TestAuthenticate(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		cfg     *listeners.WebhookAuth
+		body    string
+		headers map[string]string
+		remote  string
+		want    error
+	}{
+		{
+			name:    "github signature",
+			cfg:     &listeners.WebhookAuth{Signature: signature(listeners.SignatureGitHub)},
+			headers: map[string]string{"X-Hub-Signature-256": "sha256=" + sign(testBody)},
+		},
+		{
+			name:    "github tampered body",
+			cfg:     &listeners.WebhookAuth{Signature: signature(listeners.SignatureGitHub)},
+			body:    `{"status":"resolved"}`,
+			headers: map[string]string{"X-Hub-Signature-256": "sha256=" + sign(testBody)},
+			want:    errUnauthorized,
+		},
+		{
+			name:    "github wrong prefix",
+			cfg:     &listeners.WebhookAuth{Signature: signature(listeners.SignatureGitHub)},
+			headers: map[string]string{"X-Hub-Signature-256": "sha1=" + sign(testBody)},
+			want:    errUnauthorized,
+		},
+		{
+			name: "github missing signature",
+			cfg:  &listeners.WebhookAuth{Signature: signature(listeners.SignatureGitHub)},
+			want: errUnauthorized,
+		},
+		{
+			name: "slack signature",
+			cfg:  &listeners.WebhookAuth{Signature: signature(listeners.SignatureSlack)},
+			headers: map[string]string{
+				"X-Slack-Signature":         "v0=" + sign("v0:"+now+":"+testBody),
+				"X-Slack-Request-Timestamp": now,
+			},
+		},
+		{
+			name: "slack stale timestamp",
+			cfg:  &listeners.WebhookAuth{Signature: signature(listeners.SignatureSlack)},
+			headers: map[string]string{
+				"X-Slack-Signature":         "v0=" + sign("v0:"+stale+":"+testBody),
+				"X-Slack-Request-Timestamp": stale,
+			},
+			want: errUnauthorized,
+		},
+		{
+			name:    "slack missing timestamp",
+			cfg:     &listeners.WebhookAuth{Signature: signature(listeners.SignatureSlack)},
+			headers: map[string]string{"X-Slack-Signature": "v0=" + sign("v0::"+testBody)},
+			want:    errUnauthorized,
+		},
+		{
+			name: "slack timestamp not signed",
+			cfg:  &listeners.WebhookAuth{Signature: signature(listeners.SignatureSlack)},
+			headers: map[string]string{
+				"X-Slack-Signature":         "v0=" + sign("v0:"+stale+":"+testBody),
+				"X-Slack-Request-Timestamp": now,
+			},
+			want: errUnauthorized,
+		},
+		{
+			name:    "grafana signature",
+			cfg:     &listeners.WebhookAuth{Signature: signature(listeners.SignatureGrafana)},
+			headers: map[string]string{"X-Grafana-Alerting-Signature": sign(testBody)},
+		},
+		{
+			name: "grafana signature with timestamp",
+			cfg:  &listeners.WebhookAuth{Signature: signature(listeners.SignatureGrafana)},
+			headers: map[string]string{
+				"X-Grafana-Alerting-Signature": sign(now + ":" + testBody),
+				"X-Grafana-Alerting-Timestamp": now,
+			},
+		},
+		{
+			name: "grafana stale timestamp",
+			cfg:  &listeners.WebhookAuth{Signature: signature(listeners.SignatureGrafana)},
+			headers: map[string]string{
+				"X-Grafana-Alerting-Signature": sign(stale + ":" + testBody),
+				"X-Grafana-Alerting-Timestamp": stale,
+			},
+			want: errUnauthorized,
+		},
+		{
+			name:    "grafana tampered body",
+			cfg:     &listeners.WebhookAuth{Signature: signature(listeners.SignatureGrafana)},
+			body:    `{}`,
+			headers: map[string]string{"X-Grafana-Alerting-Signature": sign(testBody)},
+			want:    errUnauthorized,
+		},
+		{
+			name:    "bearer token",
+			cfg:     &listeners.WebhookAuth{BearerToken: "token"},
+			headers: map[string]string{"Authorization": "Bearer token"},
+		},
+		{
+			name:    "wrong bearer token",
+			cfg:     &listeners.WebhookAuth{BearerToken: "token"},
+			headers: map[string]string{"Authorization": "Bearer other"},
+			want:    errUnauthorized,
+		},
+		{
+			name:    "bearer token without scheme",
+			cfg:     &listeners.WebhookAuth{BearerToken: "token"},
+			headers: map[string]string{"Authorization": "token"},
+			want:    errUnauthorized,
+		},
+		{
+			name:    "basic auth",
+			cfg:     &listeners.WebhookAuth{BasicAuth: &listeners.BasicAuth{Username: "user", Password: "password"}},
+			headers: map[string]string{"Authorization": basicAuth("user", "password")},
+		},
+		{
+			name:    "wrong basic auth password",
+			cfg:     &listeners.WebhookAuth{BasicAuth: &listeners.BasicAuth{Username: "user", Password: "password"}},
+			headers: map[string]string{"Authorization": basicAuth("user", "other")},
+			want:    errUnauthorized,
+		},
+		{
+			name: "missing basic auth",
+			cfg:  &listeners.WebhookAuth{BasicAuth: &listeners.BasicAuth{Username: "user", Password: "password"}},
+			want: errUnauthorized,
+		},
+		{
+			name:   "allowed address",
+			cfg:    &listeners.WebhookAuth{AllowedIPs: []string{"10.0.0.0/8"}},
+			remote: "10.1.2.3:4567",
+		},
+		{
+			name:   "address not allowed",
+			cfg:    &listeners.WebhookAuth{AllowedIPs: []string{"10.0.0.0/8"}},
+			remote: "192.0.2.1:4567",
+			want:   errForbidden,
+		},
+		{
+			name:    "forwarded for ignored without trust",
+			cfg:     &listeners.WebhookAuth{AllowedIPs: []string{"10.0.0.0/8"}},
+			headers: map[string]string{"X-Forwarded-For": "10.1.2.3"},
+			remote:  "192.0.2.1:4567",
+			want:    errForbidden,
+		},
+		{
+			name:    "forwarded for by trusted proxy",
+			cfg:     &listeners.WebhookAuth{AllowedIPs: []string{"10.0.0.0/8"}, TrustForwardedFor: true},
+			headers: map[string]string{"X-Forwarded-For": "10.1.2.3"},
+			remote:  "192.0.2.1:4567",
+		},
+		{
+			// Client sends the allowed address itself, the proxy appends the real one.
+			name:    "spoofed forwarded for",
+			cfg:     &listeners.WebhookAuth{AllowedIPs: []string{"10.0.0.0/8"}, TrustForwardedFor: true},
+			headers: map[string]string{"X-Forwarded-For": "10.1.2.3, 203.0.113.5"},
+			remote:  "192.0.2.1:4567",
+			want:    errForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewAuth(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := tt.body
+			if body == "" {
+				body = testBody
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/webhook/test", strings.NewReader(body))
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			if tt.remote != "" {
+				r.RemoteAddr = tt.remote
+			}
+
+			err = auth.Authenticate(r)
+			if tt.want == nil && err != nil {
+				t.Fatalf("Authenticate() = %v, want nil", err)
+			}
+
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Authenticate() = %v, want %v", err, tt.want)
+			}
+
+			// Body is read again by the listener.
+			if got, _ := io.ReadAll(r.Body); tt.want == nil && string(got) != body {
+				t.Errorf("body after Authenticate() = %q, want %q", got, body)
+			}
+		})
+	}
+}
+
+func TestNewAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *listeners.WebhookAuth
+		wantErr bool
+	}{
+		{name: "unknown style", cfg: &listeners.WebhookAuth{Signature: &listeners.WebhookSignature{Style: "gitlab", Secret: testSecret}}, wantErr: true},
+		{name: "missing secret", cfg: &listeners.WebhookAuth{Signature: &listeners.WebhookSignature{Style: listeners.SignatureGitHub}}, wantErr: true},
+		{name: "invalid address", cfg: &listeners.WebhookAuth{AllowedIPs: []string{"10.0.0"}}, wantErr: true},
+		{name: "address and range", cfg: &listeners.WebhookAuth{AllowedIPs: []string{"10.0.0.1", "192.168.0.0/16"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAuth(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("NewAuth() error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if auth, err := NewAuth(nil); auth != nil || err != nil {
+		t.Errorf("NewAuth(nil) = %v, %v, want nil", auth, err)
+	}
+}
+
+func basicAuth(user, password string) string {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.SetBasicAuth(user, password)
+
+	return r.Header.Get("Authorization")
+}
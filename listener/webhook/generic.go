@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// mapFields are alert fields of the response map.
+var mapFields = []string{"message", "from", "chat", "severity", "status"}
+
+// genericAdapter reads alert from query parameters, headers or body of the
+// request by the response map.
+type genericAdapter struct {
+	cfg  *listeners.WebhookConfig
+	log  *wlog.Logger
+	tmpl *template.Template
+}
+
+func newGenericAdapter(cfg *listeners.WebhookConfig, log *wlog.Logger) (Adapter, error) {
+	a := &genericAdapter{cfg: cfg, log: log}
+	for _, field := range cfg.ResponseMap.Required {
+		if !slices.Contains(mapFields, field) {
+			return nil, fmt.Errorf("response map: unknown required field %s", field)
+		}
+	}
+
+	if cfg.ResponseMap.Template != "" {
+		tmpl, err := template.New(cfg.Name).Option("missingkey=zero").Parse(cfg.ResponseMap.Template)
+		if err != nil {
+			return nil, fmt.Errorf("response map template: %v", err)
+		}
+
+		a.tmpl = tmpl
+	}
+
+	return a, nil
+}
+
+func (a *genericAdapter) Alerts(r *http.Request) ([]*model.Alert, error) {
+	req, err := readRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m := a.cfg.ResponseMap
+	exprs := map[string]string{
+		"message":  m.Message,
+		"from":     m.From,
+		"chat":     m.Chat,
+		"severity": m.Severity,
+		"status":   m.Status,
+	}
+
+	values := make(map[string]string, len(exprs))
+	for field, expr := range exprs {
+		if expr != "" {
+			values[field], _ = req.lookup(expr)
+		}
+	}
+
+	if a.tmpl != nil {
+		var text strings.Builder
+		if err := a.tmpl.Execute(&text, req); err != nil {
+			return nil, fmt.Errorf("execute template: %v", err)
+		}
+
+		exprs["message"] = "template"
+		values["message"] = strings.TrimSpace(text.String())
+	}
+
+	var missing []string
+	for _, field := range m.Required {
+		if values[field] != "" {
+			continue
+		}
+
+		if expr := exprs[field]; expr != "" {
+			field += " (" + expr + ")"
+		}
+
+		missing = append(missing, field)
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("required fields missing: %s", strings.Join(missing, ", "))
+	}
+
+	alert := &model.Alert{
+		Channel:  a.cfg.Name,
+		Text:     values["message"],
+		From:     values["from"],
+		Chat:     values["chat"],
+		Severity: parseSeverity(a.log, values["severity"]),
+	}
+
+	if v := values["status"]; v != "" {
+		alert.Status = model.ParseStatus(v)
+	}
+
+	return []*model.Alert{alert}, nil
+}
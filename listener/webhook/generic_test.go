@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+func genericConfig(m listeners.WebhookResponseMap) *listeners.WebhookConfig {
+	return &listeners.WebhookConfig{Format: listeners.WebhookFormatGeneric, ResponseMap: m}
+}
+
+func TestGenericAdapter(t *testing.T) {
+	cfg := genericConfig(listeners.WebhookResponseMap{
+		Message:  "body.heartbeat.msg",
+		From:     "body.monitor.name",
+		Chat:     "body.labels.team",
+		Severity: "query.severity",
+		Status:   "body.heartbeat.status",
+		Required: []string{"message", "from"},
+	})
+
+	t.Run("firing", func(t *testing.T) {
+		alerts, err := adapterAlerts(t, cfg, fixture(t, "generic_firing.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a := alerts[0]
+		if a.Text != "Request failed with status code 503" || a.From != "Checkout page" || a.Chat != "payments" {
+			t.Errorf("alert text %q, from %q, chat %q", a.Text, a.From, a.Chat)
+		}
+
+		// Unknown statuses are firing, severity is left to the rules of the listener.
+		if a.Status != model.StatusFiring || a.Severity != "" {
+			t.Errorf("alert status %s, severity %q, want firing without severity", a.Status, a.Severity)
+		}
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		alerts, err := adapterAlerts(t, cfg, fixture(t, "generic_resolved.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if alerts[0].Status != model.StatusResolved {
+			t.Errorf("alert status %s, want resolved", alerts[0].Status)
+		}
+	})
+
+	t.Run("template", func(t *testing.T) {
+		cfg := genericConfig(listeners.WebhookResponseMap{
+			Template: `{{ .Get "body.monitor.name" }} is {{ .Get "body.heartbeat.status" }}: {{ .Get "body.heartbeat.code" }} {{ .Get "body.missing" }}`,
+			Required: []string{"message"},
+		})
+
+		alerts, err := adapterAlerts(t, cfg, fixture(t, "generic_firing.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := alerts[0].Text, "Checkout page is down: 503"; got != want {
+			t.Errorf("alert text %q, want %q", got, want)
+		}
+	})
+
+	t.Run("missing required fields", func(t *testing.T) {
+		_, err := adapterAlerts(t, cfg, `{"heartbeat":{"msg":"down"}}`)
+		if err == nil || !strings.Contains(err.Error(), "from (body.monitor.name)") {
+			t.Fatalf("Alerts() error %v, want from (body.monitor.name) missing", err)
+		}
+	})
+
+	t.Run("unknown required field", func(t *testing.T) {
+		cfg := genericConfig(listeners.WebhookResponseMap{Message: "message", Required: []string{"text"}})
+		if _, err := newAdapter(cfg, wlog.NewLogger(&wlog.LoggerConfiguration{})); err == nil {
+			t.Fatal("adapter with unknown required field created")
+		}
+	})
+}
+
+func TestHandlerMissingRequiredFields(t *testing.T) {
+	cfg := genericConfig(listeners.DefaultWebhookConfig.ResponseMap)
+	cfg.Name = "uptime"
+	cfg.ResponseMap.Message = "body.message"
+
+	adapter, err := newAdapter(cfg, wlog.NewLogger(&wlog.LoggerConfiguration{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pushed []*model.Alert
+	h := &Handler{listeners: make(map[string]*listener)}
+	err = h.RegisterListener(cfg.Name, "token", nil, func(r *http.Request) error {
+		alerts, err := adapter.Alerts(r)
+		pushed = append(pushed, alerts...)
+
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "missing", body: `{"text":"down"}`, want: http.StatusBadRequest},
+		{name: "present", body: `{"message":"down"}`, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/webhook/uptime/token", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			r.SetPathValue("name", "uptime")
+			r.SetPathValue("token", "token")
+
+			w := httptest.NewRecorder()
+			h.handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	if len(pushed) != 1 || pushed[0].Text != "down" {
+		t.Errorf("pushed %v, want one alert", pushed)
+	}
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// grafanaMessage is the payload of Grafana unified alerting webhook contact point,
+// it extends Alertmanager payload with links to dashboards and values of queries.
+type grafanaMessage struct {
+	Receiver        string         `json:"receiver"`
+	Status          string         `json:"status"`
+	OrgID           int            `json:"orgId"`
+	GroupKey        string         `json:"groupKey"`
+	TruncatedAlerts int            `json:"truncatedAlerts"`
+	Title           string         `json:"title"`
+	Message         string         `json:"message"`
+	Alerts          []grafanaAlert `json:"alerts"`
+}
+
+type grafanaAlert struct {
+	alertmanagerAlert
+
+	DashboardURL string `json:"dashboardURL"`
+	PanelURL     string `json:"panelURL"`
+	SilenceURL   string `json:"silenceURL"`
+	ValueString  string `json:"valueString"`
+}
+
+// grafanaAdapter reads alerts from Grafana unified alerting webhook payload.
+type grafanaAdapter struct {
+	am  *alertmanagerAdapter
+	log *wlog.Logger
+}
+
+func newGrafanaAdapter(cfg *listeners.WebhookConfig, log *wlog.Logger) (Adapter, error) {
+	return &grafanaAdapter{am: &alertmanagerAdapter{cfg: cfg, log: log}, log: log}, nil
+}
+
+func (a *grafanaAdapter) Alerts(r *http.Request) ([]*model.Alert, error) {
+	var msg grafanaMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("decode grafana payload: %v", err)
+	}
+
+	if msg.TruncatedAlerts > 0 {
+		a.log.Warn("grafana truncated alerts", wlog.Int("truncated", msg.TruncatedAlerts), wlog.String("group", msg.GroupKey))
+	}
+
+	alerts := make([]*model.Alert, 0, len(msg.Alerts))
+	for _, g := range msg.Alerts {
+		alert := a.am.alert(&g.alertmanagerAlert, first(g.Labels["grafana_folder"], msg.Receiver))
+
+		for name, value := range map[string]string{
+			"dashboard_url": g.DashboardURL,
+			"panel_url":     g.PanelURL,
+			"silence_url":   g.SilenceURL,
+			"value":         g.ValueString,
+		} {
+			if value == "" {
+				continue
+			}
+
+			if alert.Annotations == nil {
+				alert.Annotations = make(map[string]string)
+			}
+
+			alert.Annotations[name] = value
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
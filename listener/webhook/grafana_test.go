@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+func TestGrafanaAdapter(t *testing.T) {
+	cfg := &listeners.WebhookConfig{Format: listeners.WebhookFormatGrafana}
+
+	t.Run("firing", func(t *testing.T) {
+		alerts, err := adapterAlerts(t, cfg, fixture(t, "grafana_firing.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(alerts) != 1 {
+			t.Fatalf("decoded %d alerts, want 1", len(alerts))
+		}
+
+		a := alerts[0]
+		if a.Text != "CPU usage on web-1 is above 90%" || a.From != "web-1" || a.Chat != "High CPU usage" {
+			t.Errorf("alert text %q, from %q, chat %q", a.Text, a.From, a.Chat)
+		}
+
+		if a.Severity != model.SeverityWarning || a.Status != model.StatusFiring || a.Fingerprint != "7c2f1b3e9a6d4e01" {
+			t.Errorf("alert severity %s, status %s, fingerprint %s", a.Severity, a.Status, a.Fingerprint)
+		}
+
+		// Links and values are added to the annotations.
+		annotations := map[string]string{
+			"summary":       "CPU usage on web-1 is above 90%",
+			"dashboard_url": "https://grafana.example.com/d/rYdddlPWk?orgId=1",
+			"panel_url":     "https://grafana.example.com/d/rYdddlPWk?orgId=1&viewPanel=2",
+			"silence_url":   "https://grafana.example.com/alerting/silence/new?alertmanager=grafana&matcher=alertname%3DHigh+CPU+usage",
+			"value":         "[ var='B' labels={instance=web-1} value=93.4 ], [ var='C' labels={instance=web-1} value=1 ]",
+		}
+
+		if !maps.Equal(a.Annotations, annotations) {
+			t.Errorf("alert annotations %v, want %v", a.Annotations, annotations)
+		}
+
+		if a.Labels["grafana_folder"] != "Infrastructure" {
+			t.Errorf("alert labels %v, want grafana_folder preserved", a.Labels)
+		}
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		alerts, err := adapterAlerts(t, cfg, fixture(t, "grafana_resolved.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(alerts) != 1 {
+			t.Fatalf("decoded %d alerts, want 1", len(alerts))
+		}
+
+		a := alerts[0]
+		if a.Status != model.StatusResolved || a.Fingerprint != "7c2f1b3e9a6d4e01" {
+			t.Errorf("alert status %s, fingerprint %s, want resolved 7c2f1b3e9a6d4e01", a.Status, a.Fingerprint)
+		}
+
+		// Empty links are not added.
+		if _, ok := a.Annotations["dashboard_url"]; ok {
+			t.Errorf("alert annotations %v, want no dashboard_url", a.Annotations)
+		}
+	})
+}
@@ -1,8 +1,10 @@
 package webhook
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/kirychukyurii/notificator/server"
 )
@@ -11,10 +13,12 @@ type listenerFunc func(r *http.Request) error
 
 type listener struct {
 	token string
+	auth  *Auth
 	f     listenerFunc
 }
 
 type Handler struct {
+	mu        sync.RWMutex
 	listeners map[string]*listener
 }
 
@@ -23,28 +27,44 @@ func NewHandler(srv *server.Server) *Handler {
 		listeners: make(map[string]*listener),
 	}
 
-	srv.HandleFunc("/{name}/{token}", h.handler)
-	
+	// Webhooks are mounted under the prefix, so their names never shadow
+	// other endpoints like /silences or /ack/{id}.
+	srv.HandleFunc("/webhook/{name}/{token}", h.handler)
+	srv.HandleFunc("/webhook/{name}", h.handler)
+
 	return h
 }
 
-func (s *Handler) RegisterListener(name, token string, f listenerFunc) error {
+// RegisterListener registers the webhook, requests have to pass the token in the
+// path unless it is empty and authentication is configured.
+func (s *Handler) RegisterListener(name, token string, auth *Auth, f listenerFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if _, ok := s.listeners[name]; ok {
 		return fmt.Errorf("listener with name %s already exists", name)
 	}
 
-	s.listeners[name] = &listener{token, f}
+	if token == "" && auth == nil {
+		return fmt.Errorf("listener %s: token or auth required", name)
+	}
+
+	s.listeners[name] = &listener{token, auth, f}
 
 	return nil
 }
 
 func (s *Handler) DeregisterListener(name string) {
-	if _, ok := s.listeners[name]; ok {
-		delete(s.listeners, name)
-	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.listeners, name)
 }
 
 func (s *Handler) ExistsListener(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	_, ok := s.listeners[name]
 
 	return ok
@@ -59,7 +79,9 @@ func (s *Handler) handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.mu.RLock()
 	lis, ok := s.listeners[name]
+	s.mu.RUnlock()
 	if !ok {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write([]byte("webhook not found"))
@@ -68,20 +90,32 @@ func (s *Handler) handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	token := r.PathValue("token")
-	if token == "" {
+	if token == "" && lis.token != "" {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("webhook token required"))
 
 		return
 	}
 
-	if lis.token != token {
+	if !equal(lis.token, token) {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("webhook token invalid"))
 
 		return
 	}
 
+	if err := lis.auth.Authenticate(r); err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, errForbidden) {
+			status = http.StatusForbidden
+		}
+
+		w.WriteHeader(status)
+		w.Write([]byte(err.Error()))
+
+		return
+	}
+
 	if err := lis.f(r); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte("process webhook func: " + err.Error()))
@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+const jiraIssueDeleted = "jira:issue_deleted"
+
+// jiraEvent is the payload of Jira issue webhook events.
+type jiraEvent struct {
+	WebhookEvent string    `json:"webhookEvent"`
+	User         *jiraUser `json:"user"`
+	Issue        *struct {
+		Key    string `json:"key"`
+		Self   string `json:"self"`
+		Fields struct {
+			Summary  string    `json:"summary"`
+			Reporter *jiraUser `json:"reporter"`
+			Labels   []string  `json:"labels"`
+			Priority *jiraName `json:"priority"`
+			Type     *jiraName `json:"issuetype"`
+			Project  *struct {
+				Key  string `json:"key"`
+				Name string `json:"name"`
+			} `json:"project"`
+			Status *struct {
+				Name     string `json:"name"`
+				Category *struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"status"`
+		} `json:"fields"`
+	} `json:"issue"`
+}
+
+type jiraUser struct {
+	DisplayName string `json:"displayName"`
+	Name        string `json:"name"`
+}
+
+type jiraName struct {
+	Name string `json:"name"`
+}
+
+// jiraAdapter reads alert from Jira issue events, issues are resolved when they
+// get to the done status category or deleted.
+type jiraAdapter struct {
+	cfg *listeners.WebhookConfig
+	log *wlog.Logger
+}
+
+func newJiraAdapter(cfg *listeners.WebhookConfig, log *wlog.Logger) (Adapter, error) {
+	return &jiraAdapter{cfg: cfg, log: log}, nil
+}
+
+func (a *jiraAdapter) Alerts(r *http.Request) ([]*model.Alert, error) {
+	var e jiraEvent
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&e); err != nil {
+		return nil, fmt.Errorf("decode jira payload: %v", err)
+	}
+
+	if e.Issue == nil {
+		return nil, fmt.Errorf("jira event %q has no issue", e.WebhookEvent)
+	}
+
+	issue := e.Issue
+	alert := &model.Alert{
+		Channel:     a.cfg.Name,
+		Text:        strings.TrimSpace(issue.Key + ": " + issue.Fields.Summary),
+		From:        first(e.User.name(), issue.Fields.Reporter.name()),
+		Status:      model.StatusFiring,
+		Fingerprint: "jira-" + issue.Key,
+		Labels: map[string]string{
+			"event": e.WebhookEvent,
+			"key":   issue.Key,
+		},
+	}
+
+	if p := issue.Fields.Project; p != nil {
+		alert.Chat = first(p.Name, p.Key)
+		alert.Labels["project"] = p.Key
+	}
+
+	if p := issue.Fields.Priority; p != nil {
+		alert.Severity = parseSeverity(a.log, p.Name)
+		alert.Labels["priority"] = p.Name
+	}
+
+	if t := issue.Fields.Type; t != nil {
+		alert.Labels["issue_type"] = t.Name
+	}
+
+	if s := issue.Fields.Status; s != nil {
+		alert.Labels["status"] = s.Name
+		if s.Category != nil && s.Category.Key == "done" {
+			alert.Status = model.StatusResolved
+		}
+	}
+
+	if e.WebhookEvent == jiraIssueDeleted {
+		alert.Status = model.StatusResolved
+	}
+
+	if link := jiraBrowseURL(issue.Self, issue.Key); link != "" {
+		alert.Annotations = map[string]string{"url": link}
+	}
+
+	return []*model.Alert{alert}, nil
+}
+
+func (u *jiraUser) name() string {
+	if u == nil {
+		return ""
+	}
+
+	return first(u.DisplayName, u.Name)
+}
+
+// jiraBrowseURL returns link to the issue page built from the REST API link of the issue.
+func jiraBrowseURL(self, key string) string {
+	u, err := url.Parse(self)
+	if err != nil || u.Host == "" || key == "" {
+		return ""
+	}
+
+	base, _, _ := strings.Cut(u.Path, "/rest/api/")
+
+	return u.Scheme + "://" + u.Host + base + "/browse/" + key
+}
@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+func TestJiraAdapter(t *testing.T) {
+	cfg := &listeners.WebhookConfig{Format: listeners.WebhookFormatJira}
+
+	t.Run("created", func(t *testing.T) {
+		alerts, err := adapterAlerts(t, cfg, fixture(t, "jira_issue_created.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(alerts) != 1 {
+			t.Fatalf("decoded %d alerts, want 1", len(alerts))
+		}
+
+		a := alerts[0]
+		if a.Text != "OPS-128: Payments API returns 502 for card payments" || a.From != "John Smith" || a.Chat != "Operations" {
+			t.Errorf("alert text %q, from %q, chat %q", a.Text, a.From, a.Chat)
+		}
+
+		if a.Severity != model.SeverityCritical || a.Status != model.StatusFiring || a.Fingerprint != "jira-OPS-128" {
+			t.Errorf("alert severity %s, status %s, fingerprint %s", a.Severity, a.Status, a.Fingerprint)
+		}
+
+		labels := map[string]string{
+			"event":      "jira:issue_created",
+			"key":        "OPS-128",
+			"project":    "OPS",
+			"priority":   "Highest",
+			"issue_type": "Incident",
+			"status":     "Open",
+		}
+
+		if !maps.Equal(a.Labels, labels) {
+			t.Errorf("alert labels %v, want %v", a.Labels, labels)
+		}
+
+		if got, want := a.Annotations["url"], "https://jira.example.com/browse/OPS-128"; got != want {
+			t.Errorf("alert url %q, want %q", got, want)
+		}
+	})
+
+	t.Run("done", func(t *testing.T) {
+		alerts, err := adapterAlerts(t, cfg, fixture(t, "jira_issue_done.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if a := alerts[0]; a.Status != model.StatusResolved || a.Fingerprint != "jira-OPS-128" || a.From != "Anna Kovalenko" {
+			t.Errorf("alert status %s, fingerprint %s, from %q, want resolved jira-OPS-128 by Anna Kovalenko", a.Status, a.Fingerprint, a.From)
+		}
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		body := strings.Replace(fixture(t, "jira_issue_created.json"), "jira:issue_created", jiraIssueDeleted, 1)
+		alerts, err := adapterAlerts(t, cfg, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if alerts[0].Status != model.StatusResolved {
+			t.Errorf("alert status %s, want resolved", alerts[0].Status)
+		}
+	})
+
+	t.Run("no issue", func(t *testing.T) {
+		if _, err := adapterAlerts(t, cfg, `{"webhookEvent":"project_created"}`); err == nil {
+			t.Fatal("event without issue decoded")
+		}
+	})
+}
+
+func TestJiraBrowseURL(t *testing.T) {
+	tests := []struct {
+		self, key, want string
+	}{
+		{self: "https://jira.example.com/rest/api/2/issue/10057", key: "OPS-1", want: "https://jira.example.com/browse/OPS-1"},
+		{self: "https://example.com/jira/rest/api/2/issue/10057", key: "OPS-1", want: "https://example.com/jira/browse/OPS-1"},
+		{self: "", key: "OPS-1", want: ""},
+		{self: "https://jira.example.com/rest/api/2/issue/10057", key: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := jiraBrowseURL(tt.self, tt.key); got != tt.want {
+			t.Errorf("jiraBrowseURL(%q, %q) = %q, want %q", tt.self, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Prefixes of path expressions.
+const (
+	prefixQuery  = "query"
+	prefixHeader = "header"
+	prefixBody   = "body"
+)
+
+// request holds values of the webhook request path expressions are resolved against.
+type request struct {
+	query  url.Values
+	header http.Header
+
+	// body is decoded JSON body, form holds form-encoded body.
+	body any
+	form url.Values
+}
+
+// readRequest reads query parameters, headers and the body of the request.
+func readRequest(r *http.Request) (*request, error) {
+	req := &request{
+		query:  r.URL.Query(),
+		header: r.Header,
+	}
+
+	if r.Body == nil {
+		return req, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		r.Body = http.MaxBytesReader(nil, r.Body, maxBodySize)
+		if err := r.ParseMultipartForm(maxBodySize); err != nil && err != http.ErrNotMultipart {
+			return nil, fmt.Errorf("parse form: %v", err)
+		}
+
+		req.form = r.PostForm
+	default:
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("read body: %v", err)
+		}
+
+		if len(bytes.TrimSpace(data)) == 0 {
+			return req, nil
+		}
+
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		if err := d.Decode(&req.body); err != nil {
+			// Body is not required to be JSON unless it is referenced.
+			if strings.Contains(mediaType, "json") {
+				return nil, fmt.Errorf("decode body: %v", err)
+			}
+
+			req.body = nil
+		}
+	}
+
+	return req, nil
+}
+
+// Get returns value of the path expression, empty if not found. It is used by
+// templates of the response map.
+func (r *request) Get(expr string) string {
+	v, _ := r.lookup(expr)
+
+	return v
+}
+
+// lookup resolves the path expression: query.<name>, header.<name> or body.<path>,
+// expression without a known prefix is a query parameter.
+func (r *request) lookup(expr string) (string, bool) {
+	prefix, path, _ := strings.Cut(expr, ".")
+	switch prefix {
+	case prefixQuery:
+		return value(r.query, path)
+	case prefixHeader:
+		if v := r.header.Values(path); len(v) > 0 {
+			return v[0], true
+		}
+
+		return "", false
+	case prefixBody:
+		if r.form != nil {
+			return value(r.form, path)
+		}
+
+		return lookupJSON(r.body, path)
+	}
+
+	return value(r.query, expr)
+}
+
+func value(values url.Values, key string) (string, bool) {
+	if v, ok := values[key]; ok && len(v) > 0 {
+		return v[0], true
+	}
+
+	return "", false
+}
+
+// lookupJSON walks the decoded JSON by the dot separated path, numeric segments
+// index arrays and dots in keys are escaped with a backslash. Objects and arrays
+// are returned as JSON.
+func lookupJSON(v any, path string) (string, bool) {
+	if path != "" {
+		for _, key := range splitPath(path) {
+			switch node := v.(type) {
+			case map[string]any:
+				child, ok := node[key]
+				if !ok {
+					return "", false
+				}
+
+				v = child
+			case []any:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(node) {
+					return "", false
+				}
+
+				v = node[i]
+			default:
+				return "", false
+			}
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+
+		return string(data), true
+	}
+}
+
+func splitPath(path string) []string {
+	var (
+		keys []string
+		key  strings.Builder
+	)
+
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == '\\' && i+1 < len(path):
+			i++
+			key.WriteByte(path[i])
+		case c == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(c)
+		}
+	}
+
+	return append(keys, key.String())
+}
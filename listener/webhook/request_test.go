@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRequestLookup(t *testing.T) {
+	newRequest := func(t *testing.T, contentType, body string) *request {
+		r := httptest.NewRequest(http.MethodPost, "/webhook/test?host=web-1&text=query+text", strings.NewReader(body))
+		r.Header.Set("X-Source", "uptime")
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+
+		req, err := readRequest(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return req
+	}
+
+	jsonReq := newRequest(t, "application/json", fixture(t, "generic_firing.json"))
+	formReq := newRequest(t, "application/x-www-form-urlencoded", "message=form+text&host.name=web-3")
+
+	tests := []struct {
+		name   string
+		req    *request
+		expr   string
+		want   string
+		wantOK bool
+	}{
+		{name: "query", req: jsonReq, expr: "query.host", want: "web-1", wantOK: true},
+		{name: "no prefix is query", req: jsonReq, expr: "text", want: "query text", wantOK: true},
+		{name: "unknown prefix is query", req: jsonReq, expr: "params.host"},
+		{name: "missing query", req: jsonReq, expr: "query.chat"},
+		{name: "header", req: jsonReq, expr: "header.X-Source", want: "uptime", wantOK: true},
+		{name: "header is case insensitive", req: jsonReq, expr: "header.x-source", want: "uptime", wantOK: true},
+		{name: "missing header", req: jsonReq, expr: "header.X-Missing"},
+		{name: "body string", req: jsonReq, expr: "body.monitor.name", want: "Checkout page", wantOK: true},
+		{name: "body number", req: jsonReq, expr: "body.heartbeat.code", want: "503", wantOK: true},
+		{name: "body bool", req: jsonReq, expr: "body.heartbeat.important", want: "true", wantOK: true},
+		{name: "body object", req: jsonReq, expr: "body.alerts.0.labels", want: `{"host":"web-1"}`, wantOK: true},
+		{name: "body array index", req: jsonReq, expr: "body.alerts.1.labels.host", want: "web-2", wantOK: true},
+		{name: "body array index out of range", req: jsonReq, expr: "body.alerts.2.labels.host"},
+		{name: "body negative array index", req: jsonReq, expr: "body.alerts.-1.labels.host"},
+		{name: "body array key", req: jsonReq, expr: "body.alerts.first"},
+		{name: "body escaped dots", req: jsonReq, expr: `body.labels.app\.kubernetes\.io/name`, want: "checkout", wantOK: true},
+		{name: "body unescaped dots", req: jsonReq, expr: "body.labels.app.kubernetes.io/name"},
+		{name: "body path into value", req: jsonReq, expr: "body.monitor.name.first"},
+		{name: "form", req: formReq, expr: "body.message", want: "form text", wantOK: true},
+		{name: "form key with dots", req: formReq, expr: "body.host.name", want: "web-3", wantOK: true},
+		{name: "missing form key", req: formReq, expr: "body.chat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.req.lookup(tt.expr)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("lookup(%q) = %q, %v, want %q, %v", tt.expr, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReadRequestBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     bool
+	}{
+		{name: "empty body", contentType: "application/json"},
+		{name: "invalid json", contentType: "application/json", body: `{"message":`, wantErr: true},
+		{name: "plain text is not decoded", contentType: "text/plain", body: "down"},
+		{name: "json without content type", body: `{"message":"down"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/webhook/test", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			if _, err := readRequest(r); (err != nil) != tt.wantErr {
+				t.Errorf("readRequest() error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "message", want: []string{"message"}},
+		{path: "alerts.0.labels.host", want: []string{"alerts", "0", "labels", "host"}},
+		{path: `labels.app\.kubernetes\.io/name`, want: []string{"labels", "app.kubernetes.io/name"}},
+		{path: `a\\.b`, want: []string{`a\`, "b"}},
+		{path: `trailing\`, want: []string{`trailing\`}},
+		{path: "a..b", want: []string{"a", "", "b"}},
+	}
+
+	for _, tt := range tests {
+		if got := splitPath(tt.path); !slices.Equal(got, tt.want) {
+			t.Errorf("splitPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
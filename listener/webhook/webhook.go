@@ -8,7 +8,7 @@ import (
 	"github.com/webitel/wlog"
 
 	"github.com/kirychukyurii/notificator/config/listeners"
-	"github.com/kirychukyurii/notificator/model"
+	"github.com/kirychukyurii/notificator/listener/severity"
 	"github.com/kirychukyurii/notificator/notifier"
 )
 
@@ -16,8 +16,11 @@ type Webhook struct {
 	cfg *listeners.WebhookConfig
 	log *wlog.Logger
 
-	handler *Handler
-	queue   *notifier.Queue
+	handler  *Handler
+	auth     *Auth
+	adapter  Adapter
+	queue    *notifier.Queue
+	severity *severity.Classifier
 }
 
 func New(cfg *listeners.WebhookConfig, log *wlog.Logger, queue *notifier.Queue, handler *Handler) (*Webhook, error) {
@@ -25,16 +28,34 @@ func New(cfg *listeners.WebhookConfig, log *wlog.Logger, queue *notifier.Queue,
 		return nil, fmt.Errorf("webhook %s already exists", cfg.Name)
 	}
 
+	adapter, err := newAdapter(cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: %v", cfg.Name, err)
+	}
+
+	auth, err := NewAuth(cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: auth: %v", cfg.Name, err)
+	}
+
+	classifier, err := severity.New(cfg.Severity)
+	if err != nil {
+		return nil, fmt.Errorf("severity: %v", err)
+	}
+
 	return &Webhook{
-		cfg:     cfg,
-		log:     log,
-		handler: handler,
-		queue:   queue,
+		cfg:      cfg,
+		log:      log,
+		handler:  handler,
+		auth:     auth,
+		adapter:  adapter,
+		queue:    queue,
+		severity: classifier,
 	}, nil
 }
 
 func (w *Webhook) Listen(ctx context.Context) error {
-	if err := w.handler.RegisterListener(w.cfg.Name, w.cfg.Token, w.handlerFunc); err != nil {
+	if err := w.handler.RegisterListener(w.cfg.Name, w.cfg.Token, w.auth, w.handlerFunc); err != nil {
 		return err
 	}
 
@@ -54,17 +75,19 @@ func (w *Webhook) Close() error {
 }
 
 func (w *Webhook) handlerFunc(r *http.Request) error {
-	alert := &model.Alert{
-		Channel: w.cfg.Name,
-		Text:    r.URL.Query().Get(w.cfg.ResponseMap.Message),
-		From:    r.URL.Query().Get(w.cfg.ResponseMap.From),
-		Chat:    r.URL.Query().Get(w.cfg.ResponseMap.Chat),
+	alerts, err := w.adapter.Alerts(r)
+	if err != nil {
+		return err
 	}
 
-	w.queue.Push(&notifier.Message{
-		Channel: w.cfg.Name,
-		Content: alert,
-	})
+	for _, alert := range alerts {
+		w.severity.Apply(alert)
+
+		w.queue.Push(&notifier.Message{
+			Channel: w.cfg.Name,
+			Content: alert,
+		})
+	}
 
 	return nil
 }
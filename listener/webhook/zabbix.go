@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// zabbixMessage is the JSON posted by Zabbix webhook media type. Zabbix lets the
+// media type script build any payload, so the script has to post its parameters
+// filled with the macros noted next to the fields.
+type zabbixMessage struct {
+	Subject  string      `json:"subject"`      // {ALERT.SUBJECT}
+	Message  string      `json:"message"`      // {ALERT.MESSAGE}
+	EventID  string      `json:"event_id"`     // {EVENT.ID}
+	Value    string      `json:"event_value"`  // {EVENT.VALUE}
+	Status   string      `json:"event_status"` // {EVENT.STATUS}
+	Severity string      `json:"severity"`     // {EVENT.SEVERITY}
+	Host     string      `json:"host"`         // {HOST.NAME}
+	Trigger  string      `json:"trigger"`      // {TRIGGER.NAME}
+	Tags     []zabbixTag `json:"tags"`         // {EVENT.TAGSJSON}
+}
+
+type zabbixTag struct {
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+// zabbixAdapter reads alert from Zabbix webhook media type, recovery messages
+// resolve the problem alert since both carry ID of the problem event.
+type zabbixAdapter struct {
+	cfg *listeners.WebhookConfig
+	log *wlog.Logger
+}
+
+func newZabbixAdapter(cfg *listeners.WebhookConfig, log *wlog.Logger) (Adapter, error) {
+	return &zabbixAdapter{cfg: cfg, log: log}, nil
+}
+
+func (a *zabbixAdapter) Alerts(r *http.Request) ([]*model.Alert, error) {
+	var msg zabbixMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("decode zabbix payload: %v", err)
+	}
+
+	alert := &model.Alert{
+		Channel:  a.cfg.Name,
+		Text:     strings.TrimSpace(first(msg.Subject, msg.Trigger) + "\n" + msg.Message),
+		From:     msg.Host,
+		Chat:     msg.Trigger,
+		Severity: parseSeverity(a.log, msg.Severity),
+		Status:   model.StatusFiring,
+	}
+
+	// {EVENT.VALUE} is 0 for recovery events, {EVENT.STATUS} is RESOLVED.
+	if msg.Value == "0" || model.ParseStatus(msg.Status) == model.StatusResolved {
+		alert.Status = model.StatusResolved
+	}
+
+	if msg.EventID != "" {
+		alert.Fingerprint = "zabbix-" + msg.EventID
+	}
+
+	alert.Labels = make(map[string]string, len(msg.Tags)+3)
+	for _, t := range msg.Tags {
+		alert.Labels[t.Tag] = t.Value
+	}
+
+	alert.Labels["host"] = msg.Host
+	alert.Labels["trigger"] = msg.Trigger
+	alert.Labels["event_id"] = msg.EventID
+
+	return []*model.Alert{alert}, nil
+}
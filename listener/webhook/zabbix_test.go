@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/kirychukyurii/notificator/config/listeners"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+func TestZabbixAdapter(t *testing.T) {
+	cfg := &listeners.WebhookConfig{Format: listeners.WebhookFormatZabbix}
+
+	problem, err := adapterAlerts(t, cfg, fixture(t, "zabbix_problem.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problem) != 1 {
+		t.Fatalf("decoded %d alerts, want 1", len(problem))
+	}
+
+	a := problem[0]
+	text := "Problem: Zabbix agent is not available (for 3m)\nProblem started at 03:14:07 on 2024.03.18\n" +
+		"Problem name: Zabbix agent is not available (for 3m)\nHost: app-3\nSeverity: High\n" +
+		"Operational data: not available (0)\nOriginal problem ID: 48213"
+	if a.Text != text {
+		t.Errorf("alert text %q, want %q", a.Text, text)
+	}
+
+	if a.From != "app-3" || a.Chat != "Zabbix agent is not available (for 3m)" {
+		t.Errorf("alert from %q, chat %q", a.From, a.Chat)
+	}
+
+	if a.Severity != model.SeverityCritical || a.Status != model.StatusFiring || a.Fingerprint != "zabbix-48213" {
+		t.Errorf("alert severity %s, status %s, fingerprint %s", a.Severity, a.Status, a.Fingerprint)
+	}
+
+	labels := map[string]string{
+		"class":     "os",
+		"component": "system",
+		"scope":     "availability",
+		"host":      "app-3",
+		"trigger":   "Zabbix agent is not available (for 3m)",
+		"event_id":  "48213",
+	}
+
+	if !maps.Equal(a.Labels, labels) {
+		t.Errorf("alert labels %v, want %v", a.Labels, labels)
+	}
+
+	// Recovery carries ID of the problem event, so it resolves the problem alert.
+	recovery, err := adapterAlerts(t, cfg, fixture(t, "zabbix_recovery.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := recovery[0]; r.Status != model.StatusResolved || r.Fingerprint != a.Fingerprint {
+		t.Errorf("recovery status %s, fingerprint %s, want resolved %s", r.Status, r.Fingerprint, a.Fingerprint)
+	}
+
+	// Either of the macros reports the recovery.
+	for _, body := range []string{
+		`{"event_id":"1","event_value":"0"}`,
+		`{"event_id":"1","event_status":"RESOLVED"}`,
+	} {
+		alerts, err := adapterAlerts(t, cfg, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if alerts[0].Status != model.StatusResolved {
+			t.Errorf("%s: alert status %s, want resolved", body, alerts[0].Status)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package manager
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
@@ -11,6 +15,15 @@ import (
 	"github.com/kirychukyurii/notificator/config"
 )
 
+// ackPrefix prefixes callback data of acknowledge buttons.
+const ackPrefix = "ack:"
+
+// AckFunc acknowledges alert group notification by its ID on behalf of the user.
+type AckFunc func(id, by string) error
+
+// CommandFunc handles the command sent to the manager chat, its result is sent as reply.
+type CommandFunc func(args, by string) (string, error)
+
 type Bot struct {
 	cfg *config.Manager
 	log *wlog.Logger
@@ -18,7 +31,19 @@ type Bot struct {
 	cli *telego.Bot
 	bh  *th.BotHandler
 
-	onduty chan string
+	onduty   chan string
+	chooseID atomic.Int64
+	done     chan struct{}
+
+	mu sync.RWMutex
+
+	// choosing is closed when the choice of the technical on duty is no longer awaited.
+	choosing <-chan struct{}
+	onAck    AckFunc
+	commands map[string]CommandFunc
+
+	// secret commands are deleted from the chat once handled, e.g. with passwords.
+	secret map[string]bool
 }
 
 func NewBot(cfg *config.Manager, log *wlog.Logger) (*Bot, error) {
@@ -28,7 +53,7 @@ func NewBot(cfg *config.Manager, log *wlog.Logger) (*Bot, error) {
 	}
 
 	opts := &telego.GetUpdatesParams{
-		AllowedUpdates: []string{"callback_query"},
+		AllowedUpdates: []string{"callback_query", "message"},
 	}
 
 	updates, err := bot.UpdatesViaLongPolling(opts)
@@ -42,24 +67,40 @@ func NewBot(cfg *config.Manager, log *wlog.Logger) (*Bot, error) {
 		return nil, err
 	}
 
-	return &Bot{
-		cfg: cfg,
-		log: log,
-		cli: bot,
-		bh:  bh,
-	}, nil
+	b := &Bot{
+		cfg:      cfg,
+		log:      log,
+		cli:      bot,
+		bh:       bh,
+		onduty:   make(chan string),
+		done:     make(chan struct{}),
+		commands: make(map[string]CommandFunc),
+		secret:   make(map[string]bool),
+	}
+
+	bh.Handle(b.handleAck, th.CallbackDataPrefix(ackPrefix))
+	bh.Handle(b.handle, th.AnyCallbackQueryWithMessage())
+	bh.Handle(b.handleCommand, th.AnyCommand())
+	go bh.Start()
+
+	return b, nil
 }
 
 func (b *Bot) Close() error {
 	b.cli.StopLongPolling()
 	b.bh.Stop()
-	close(b.onduty)
+	close(b.done)
 
 	return nil
 }
 
-func (b *Bot) ChooseTechnicals(technicals []*config.Technical) error {
-	b.onduty = make(chan string)
+// ChooseTechnicals asks to choose the technical on duty, the choice is passed
+// to the OnDuty channel until the context is done.
+func (b *Bot) ChooseTechnicals(ctx context.Context, technicals []*config.Technical) error {
+	b.mu.Lock()
+	b.choosing = ctx.Done()
+	b.mu.Unlock()
+
 	row := make([]telego.InlineKeyboardButton, 0, len(technicals))
 	for _, technical := range technicals {
 		row = append(row, tu.InlineKeyboardButton(technical.Name).WithCallbackData(technical.Phone))
@@ -85,9 +126,7 @@ func (b *Bot) ChooseTechnicals(technicals []*config.Technical) error {
 	}
 
 	b.log.Info(fmt.Sprintf("message was sent to %d, please, choose technical onduty", b.cfg.ChatID), wlog.Any("technicals", technicals))
-
-	b.bh.Handle(b.handle(m.MessageID))
-	go b.bh.Start()
+	b.chooseID.Store(int64(m.MessageID))
 
 	return nil
 }
@@ -96,26 +135,147 @@ func (b *Bot) OnDuty() chan string {
 	return b.onduty
 }
 
-func (b *Bot) handle(id int) th.Handler {
-	return func(bot *telego.Bot, update telego.Update) {
-		if id == update.CallbackQuery.Message.GetMessageID() {
-			b.log.Info("received onduty technical", wlog.String("phone", update.CallbackQuery.Data))
-			b.onduty <- update.CallbackQuery.Data
+func (b *Bot) handle(bot *telego.Bot, update telego.Update) {
+	// Accept only the first choice for the latest message.
+	id := update.CallbackQuery.Message.GetMessageID()
+	if id == 0 || !b.chooseID.CompareAndSwap(int64(id), 0) {
+		return
+	}
+
+	b.mu.RLock()
+	choosing := b.choosing
+	b.mu.RUnlock()
 
-			opts := &telego.EditMessageTextParams{
-				ChatID: telego.ChatID{
-					ID: b.cfg.ChatID,
-				},
-				MessageID: update.CallbackQuery.Message.GetMessageID(),
-				Text:      fmt.Sprintf("Received onduty technical: %s", update.CallbackQuery.Data),
-			}
+	b.log.Info("received onduty technical", wlog.String("phone", update.CallbackQuery.Data))
+	select {
+	case b.onduty <- update.CallbackQuery.Data:
+	case <-choosing:
+		b.log.Warn("choice of technical on duty is not awaited anymore", wlog.String("phone", update.CallbackQuery.Data))
+
+		return
+	case <-b.done:
+		return
+	}
+
+	opts := &telego.EditMessageTextParams{
+		ChatID: telego.ChatID{
+			ID: b.cfg.ChatID,
+		},
+		MessageID: id,
+		Text:      fmt.Sprintf("Received onduty technical: %s", update.CallbackQuery.Data),
+	}
+
+	_, err := bot.EditMessageText(opts)
+	if err != nil {
+		return
+	}
+}
+
+// HandleAck sets the function called when acknowledge button is pressed.
+func (b *Bot) HandleAck(f AckFunc) {
+	b.mu.Lock()
+	b.onAck = f
+	b.mu.Unlock()
+}
+
+// SendAckRequest sends text with the button to acknowledge notification by its ID.
+func (b *Bot) SendAckRequest(id, text string) (*telego.Message, error) {
+	button := tu.InlineKeyboardButton("Acknowledge").WithCallbackData(ackPrefix + id)
+
+	return b.SendMessage(tu.Message(tu.ID(b.cfg.ChatID), text).WithReplyMarkup(tu.InlineKeyboard(tu.InlineKeyboardRow(button))))
+}
+
+func (b *Bot) handleAck(bot *telego.Bot, update telego.Update) {
+	query := update.CallbackQuery
+	id := strings.TrimPrefix(query.Data, ackPrefix)
+
+	by := query.From.Username
+	if by == "" {
+		by = strings.TrimSpace(query.From.FirstName + " " + query.From.LastName)
+	}
+
+	b.mu.RLock()
+	onAck := b.onAck
+	b.mu.RUnlock()
 
-			_, err := bot.EditMessageText(opts)
-			if err != nil {
-				return
+	answer := "Acknowledged"
+	if onAck == nil {
+		answer = "Acknowledgement is not enabled"
+	} else if err := onAck(id, by); err != nil {
+		b.log.Warn("acknowledge notification", wlog.Err(err), wlog.String("id", id), wlog.String("by", by))
+		answer = "Acknowledge: " + err.Error()
+	}
+
+	if err := bot.AnswerCallbackQuery(tu.CallbackQuery(query.ID).WithText(answer)); err != nil {
+		b.log.Warn("answer callback query", wlog.Err(err))
+	}
+}
+
+// HandleCommand sets the function called when the command is sent to the manager chat.
+func (b *Bot) HandleCommand(name string, f CommandFunc) {
+	b.mu.Lock()
+	b.commands[name] = f
+	b.mu.Unlock()
+}
+
+// HandleSecretCommand is like HandleCommand, but the message with the command
+// is deleted from the chat once handled, since its arguments are secret.
+func (b *Bot) HandleSecretCommand(name string, f CommandFunc) {
+	b.mu.Lock()
+	b.commands[name] = f
+	b.secret[name] = true
+	b.mu.Unlock()
+}
+
+func (b *Bot) handleCommand(bot *telego.Bot, update telego.Update) {
+	message := update.Message
+	if message.Chat.ID != b.cfg.ChatID {
+		return
+	}
+
+	matches := th.CommandRegexp.FindStringSubmatch(message.Text)
+	if matches == nil {
+		return
+	}
+
+	b.mu.RLock()
+	f, ok := b.commands[matches[1]]
+	secret := b.secret[matches[1]]
+	b.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	if secret {
+		defer func() {
+			if err := bot.DeleteMessage(tu.Delete(tu.ID(b.cfg.ChatID), message.MessageID)); err != nil {
+				b.log.Warn("delete secret command", wlog.Err(err), wlog.String("command", matches[1]))
 			}
+		}()
+	}
+
+	by := ""
+	if message.From != nil {
+		by = message.From.Username
+		if by == "" {
+			by = strings.TrimSpace(message.From.FirstName + " " + message.From.LastName)
 		}
 	}
+
+	reply, err := f(strings.TrimSpace(matches[3]), by)
+	if err != nil {
+		b.log.Warn("handle command", wlog.Err(err), wlog.String("command", matches[1]), wlog.String("by", by))
+		reply = matches[1] + ": " + err.Error()
+	}
+
+	if reply == "" {
+		return
+	}
+
+	params := tu.Message(tu.ID(b.cfg.ChatID), reply).WithReplyParameters(&telego.ReplyParameters{MessageID: message.MessageID})
+	if _, err := bot.SendMessage(params); err != nil {
+		b.log.Warn("send command reply", wlog.Err(err))
+	}
 }
 
 func (b *Bot) SendMessage(message *telego.SendMessageParams) (*telego.Message, error) {
@@ -131,6 +291,18 @@ func (b *Bot) SendMessage(message *telego.SendMessageParams) (*telego.Message, e
 	return m, nil
 }
 
+func (b *Bot) SendPhoto(photo *telego.SendPhotoParams) (*telego.Message, error) {
+	photo.ChatID = telego.ChatID{
+		ID: b.cfg.ChatID,
+	}
+
+	return b.cli.SendPhoto(photo)
+}
+
+func (b *Bot) DeleteMessage(id int) error {
+	return b.cli.DeleteMessage(tu.Delete(tu.ID(b.cfg.ChatID), id))
+}
+
 func (b *Bot) EditMessage(message *telego.EditMessageTextParams) error {
 	message.ChatID = telego.ChatID{
 		ID: b.cfg.ChatID,
@@ -1,16 +1,83 @@
 package model
 
 import (
+	"fmt"
 	"strings"
+	"time"
 )
 
+// LabelPrefix prefixes names of alert labels among alert fields, e.g. labels.alertname.
+const LabelPrefix = "labels."
+
 type Alert struct {
-	Channel string
-	Text    string
-	From    string
-	Chat    string
+	Channel string `json:"channel"`
+	Text    string `json:"text"`
+	From    string `json:"from,omitempty"`
+	Chat    string `json:"chat,omitempty"`
+
+	Severity Severity `json:"severity,omitempty"`
+
+	// Status is firing unless the source reported the alert as resolved.
+	Status Status `json:"status,omitempty"`
+
+	// Fingerprint identifies duplicates of the alert, Repeats counts duplicates
+	// suppressed in the group.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Repeats     int    `json:"repeats,omitempty"`
+
+	// Thread is a link to the thread the message was posted in, if any.
+	Thread string `json:"thread,omitempty"`
+
+	// MessageID and Link identify the source message, if the source has them.
+	MessageID string `json:"message_id,omitempty"`
+	Link      string `json:"link,omitempty"`
+
+	// Labels and Annotations are set by sources like Prometheus Alertmanager,
+	// labels can be used for routing and grouping.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Attachments are media of the source message, e.g. screenshots or documents.
+	Attachments []*Attachment `json:"attachments,omitempty"`
+
+	// StartsAt and EndsAt are reported by the source, zero if unknown.
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
 }
 
 func (a *Alert) String() string {
-	return strings.Join([]string{a.Channel, a.From, a.Text}, ": ")
+	s := strings.Join([]string{a.Channel, a.From, a.Text}, ": ")
+	if a.Repeats > 0 {
+		s += fmt.Sprintf(" (repeated %d times)", a.Repeats)
+	}
+
+	return s
+}
+
+// Field returns value of the alert field by its name, used for grouping and matching.
+func (a *Alert) Field(name string) string {
+	switch name {
+	case "channel":
+		return a.Channel
+	case "chat":
+		return a.Chat
+	case "from":
+		return a.From
+	case "text":
+		return a.Text
+	case "thread":
+		return a.Thread
+	case "severity":
+		return string(a.Severity)
+	case "fingerprint":
+		return a.Fingerprint
+	case "status":
+		return string(a.Status)
+	}
+
+	if label, ok := strings.CutPrefix(name, LabelPrefix); ok {
+		return a.Labels[label]
+	}
+
+	return ""
 }
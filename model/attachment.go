@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Types of attachments.
+const (
+	AttachmentPhoto    = "photo"
+	AttachmentDocument = "document"
+	AttachmentVideo    = "video"
+	AttachmentVoice    = "voice"
+	AttachmentAudio    = "audio"
+	AttachmentSticker  = "sticker"
+	AttachmentLocation = "location"
+	AttachmentContact  = "contact"
+	AttachmentPoll     = "poll"
+)
+
+// Attachment describes a file or other media of the source message. Path and
+// URL are set if the file is saved to the attachment store.
+type Attachment struct {
+	Type     string        `json:"type"`
+	Name     string        `json:"name,omitempty"`
+	MimeType string        `json:"mime_type,omitempty"`
+	Size     int64         `json:"size,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+
+	Path string `json:"path,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// String returns short description of the attachment, e.g. [document report.pdf, 1.2 MB].
+func (a *Attachment) String() string {
+	parts := []string{a.Type}
+	if a.Name != "" {
+		parts = append(parts, a.Name)
+	}
+
+	desc := strings.Join(parts, " ")
+	var details []string
+	if a.Size > 0 {
+		details = append(details, formatSize(a.Size))
+	}
+
+	if a.Duration > 0 {
+		details = append(details, a.Duration.Round(time.Second).String())
+	}
+
+	if len(details) > 0 {
+		desc += ", " + strings.Join(details, ", ")
+	}
+
+	return "[" + desc + "]"
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
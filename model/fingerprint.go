@@ -0,0 +1,37 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+var numbers = regexp.MustCompile(`\d+`)
+
+// NormalizeText lowercases the text and collapses whitespaces, so messages
+// differing only in formatting are considered the same. Numbers are replaced
+// with a placeholder if ignoreNumbers is set, e.g. for changing metric values.
+func NormalizeText(text string, ignoreNumbers bool) string {
+	text = strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	if ignoreNumbers {
+		text = numbers.ReplaceAllString(text, "#")
+	}
+
+	return text
+}
+
+// Fingerprint returns the hash of the alert fields, text is normalized before hashing.
+func Fingerprint(alert *Alert, fields []string, ignoreNumbers bool) string {
+	h := sha256.New()
+	for _, f := range fields {
+		value := alert.Field(f)
+		if f == "text" {
+			value = NormalizeText(value, ignoreNumbers)
+		}
+
+		h.Write([]byte(f + "=" + value + "\x00"))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -1,5 +1,43 @@
 package model
 
+import "time"
+
 type AuthCodeURL struct {
 	URL string
 }
+
+// Kinds of secrets requested to log in.
+const (
+	AuthCode     = "code"
+	AuthPassword = "password"
+)
+
+// AuthRequest asks the manager to enter the secret required to log in to the
+// account, e.g. login code or 2FA password. The secret is sent to Reply, it
+// can also be entered in the one-time form at FormURL.
+type AuthRequest struct {
+	ID      string
+	Account string
+	Kind    string
+	FormURL string
+	Reply   chan<- string
+}
+
+// AuthStatus reports the progress of the login requested by AuthRequest with the ID.
+type AuthStatus struct {
+	ID   string
+	Text string
+
+	// Done is set when no more statuses are reported for the request, e.g. the
+	// login is finished or the next secret is requested.
+	Done bool
+}
+
+// AuthQR is the QR code to scan in the app to log in to the account, the code
+// with the same ID is sent again when the previous one expires.
+type AuthQR struct {
+	ID      string
+	Account string
+	PNG     []byte
+	Expires time.Time
+}
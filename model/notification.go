@@ -0,0 +1,27 @@
+package model
+
+import "context"
+
+type notificationKey struct{}
+
+// Notification describes a single delivery of an alert group to notifiers.
+type Notification struct {
+	// ID identifies the delivery, it is used to acknowledge the alert group.
+	ID string `json:"id"`
+
+	// AckURL is the URL to acknowledge the alert group, empty if
+	// acknowledgement is disabled.
+	AckURL string `json:"ack_url,omitempty"`
+}
+
+// WithNotification returns a copy of the context carrying the notification.
+func WithNotification(ctx context.Context, n *Notification) context.Context {
+	return context.WithValue(ctx, notificationKey{}, n)
+}
+
+// NotificationFromContext returns the notification stored in the context, if any.
+func NotificationFromContext(ctx context.Context) (*Notification, bool) {
+	n, ok := ctx.Value(notificationKey{}).(*Notification)
+
+	return n, ok
+}
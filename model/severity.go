@@ -0,0 +1,54 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// ParseSeverity parses severity name, including common aliases used by
+// monitoring tools, e.g. high or disaster for critical.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "info", "information", "informational", "low", "lowest", "minor", "trivial", "not classified", "ok":
+		return SeverityInfo, nil
+	case "warning", "warn", "medium", "average", "major":
+		return SeverityWarning, nil
+	case "critical", "crit", "high", "highest", "blocker", "disaster", "error", "fatal":
+		return SeverityCritical, nil
+	}
+
+	return "", fmt.Errorf("unknown severity %q", s)
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityCritical:
+		return 3
+	}
+
+	return 0
+}
+
+// HighestSeverity returns the highest severity of the alerts.
+func HighestSeverity(alerts ...*Alert) Severity {
+	var highest Severity
+	for _, a := range alerts {
+		if a.Severity.rank() > highest.rank() {
+			highest = a.Severity
+		}
+	}
+
+	return highest
+}
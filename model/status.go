@@ -0,0 +1,21 @@
+package model
+
+import "strings"
+
+type Status string
+
+const (
+	StatusFiring   Status = "firing"
+	StatusResolved Status = "resolved"
+)
+
+// ParseStatus parses alert state reported by monitoring tools, everything not
+// recognized as recovery is considered firing.
+func ParseStatus(s string) Status {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "resolved", "resolve", "ok", "recovery", "recovered", "normal":
+		return StatusResolved
+	}
+
+	return StatusFiring
+}
@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mymmrac/telego"
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found or already resolved")
+
+// Acknowledge marks the alert group delivered with the notification ID as
+// acknowledged, so it is not notified again until new alerts arrive.
+func (q *Queue) Acknowledge(id, by string) error {
+	q.groupsMu.Lock()
+	g, ok := q.acks[id]
+	q.groupsMu.Unlock()
+	if !ok {
+		return ErrNotificationNotFound
+	}
+
+	g.acknowledge()
+	q.log.Info("alert group acknowledged", wlog.String("group", g.key), wlog.String("notification", id), wlog.String("by", by))
+
+	q.groupsMu.Lock()
+	messages := make([]*telego.Message, 0)
+	for _, nid := range g.notificationIDs() {
+		if m, ok := q.ackMessages[nid]; ok {
+			messages = append(messages, m)
+			delete(q.ackMessages, nid)
+		}
+	}
+	q.groupsMu.Unlock()
+
+	for _, m := range messages {
+		mp := &telego.EditMessageTextParams{
+			MessageID: m.MessageID,
+			Text:      fmt.Sprintf("%s\n\nAcknowledged by %s", m.Text, by),
+		}
+
+		if err := q.bot.EditMessage(mp); err != nil {
+			q.log.Error("edit message", wlog.Err(err))
+		}
+	}
+
+	return nil
+}
+
+// requestAck sends the alert group to the manager chat with a button to acknowledge it.
+func (q *Queue) requestAck(n *model.Notification, technicals []*config.Technical, alerts ...*model.Alert) {
+	if q.bot == nil {
+		return
+	}
+
+	names := make([]string, 0, len(technicals))
+	for _, t := range technicals {
+		names = append(names, t.Name)
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Alerts sent to %s, waiting for acknowledgement:\n", strings.Join(names, ", "))
+	for _, a := range alerts {
+		text.WriteString("\n" + a.String())
+	}
+
+	m, err := q.bot.SendAckRequest(n.ID, text.String())
+	if err != nil {
+		q.log.Error("send acknowledge request", wlog.Err(err), wlog.String("notification", n.ID))
+
+		return
+	}
+
+	q.groupsMu.Lock()
+	q.ackMessages[n.ID] = m
+	q.groupsMu.Unlock()
+}
+
+// handleAck acknowledges the alert group over HTTP, e.g. from Webitel flow
+// with the digit pressed by the technical during the call passed as dtmf parameter.
+// Request is authorized with the token of the acknowledgement URL or the API token.
+func (q *Queue) handleAck(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "notification id required", http.StatusBadRequest)
+
+		return
+	}
+
+	if !q.authorizeAck(id, r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+		return
+	}
+
+	if dtmf := r.FormValue("dtmf"); dtmf != "" && dtmf != q.ack.DTMF {
+		http.Error(w, "not acknowledged with dtmf "+dtmf, http.StatusBadRequest)
+
+		return
+	}
+
+	by := r.FormValue("by")
+	if by == "" {
+		by = "http"
+	}
+
+	if err := q.Acknowledge(id, by); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrNotificationNotFound) {
+			status = http.StatusNotFound
+		}
+
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("acknowledged"))
+}
+
+// authorizeAck reports whether the request carries the token of the notification
+// or the API token as bearer authorization.
+func (q *Queue) authorizeAck(id string, r *http.Request) bool {
+	q.groupsMu.Lock()
+	expected, ok := q.ackTokens[id]
+	q.groupsMu.Unlock()
+
+	if token := r.FormValue("token"); ok && token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+		return true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return ok && q.apiToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(q.apiToken)) == 1
+}
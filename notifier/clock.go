@@ -0,0 +1,43 @@
+package notifier
+
+import "time"
+
+// Clock provides current time and timers, so time-based logic of the queue
+// may be driven by a fake clock in tests.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+type Timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+// RealClock is the Clock backed by the time package.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{t: time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (r *realTimer) C() <-chan time.Time {
+	return r.t.C
+}
+
+func (r *realTimer) Reset(d time.Duration) bool {
+	return r.t.Reset(d)
+}
+
+func (r *realTimer) Stop() bool {
+	return r.t.Stop()
+}
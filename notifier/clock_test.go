@@ -0,0 +1,231 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// fakeClock is the Clock advanced manually by tests, timers fire when the
+// time passes their deadline.
+type fakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func newFakeClock() *fakeClock {
+	c := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.cond = sync.NewCond(&c.mu)
+
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1), deadline: c.now.Add(d), active: true}
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+
+	return t
+}
+
+// Advance moves the time forward and fires timers which deadline has passed.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.deadline.After(c.now) {
+			t.active = false
+			t.c <- c.now
+		}
+	}
+}
+
+// WaitTimers blocks until n timers are active, e.g. until the goroutine under
+// test handled the fired timer and reset it.
+func (c *fakeClock) WaitTimers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.active() < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *fakeClock) active() int {
+	var n int
+	for _, t := range c.timers {
+		if t.active {
+			n++
+		}
+	}
+
+	return n
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.active
+	t.deadline = t.clock.now.Add(d)
+	t.active = true
+	t.clock.cond.Broadcast()
+
+	return active
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.active
+	t.active = false
+
+	return active
+}
+
+// notification is a single call of the notify function of the group under test.
+type notification struct {
+	step   *config.EscalationStep
+	alerts []*model.Alert
+}
+
+// testGroup runs the aggregation group driven by the fake clock and records
+// its notifications.
+type testGroup struct {
+	t      *testing.T
+	clock  *fakeClock
+	group  *aggrGroup
+	notify chan notification
+
+	// ready is false while nobody is on duty.
+	ready atomic.Bool
+}
+
+func newTestGroup(t *testing.T, opts *GroupOptions) *testGroup {
+	t.Helper()
+
+	tg := &testGroup{
+		t:      t,
+		clock:  newFakeClock(),
+		notify: make(chan notification, 100),
+	}
+
+	tg.ready.Store(true)
+
+	log := wlog.NewLogger(&wlog.LoggerConfiguration{})
+	tg.group = newAggrGroup(log, "test", &route{id: "test", group: opts}, tg.clock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tg.group.run(ctx, func(*aggrGroup, *config.EscalationStep) bool {
+			return tg.ready.Load()
+		}, func(_ context.Context, _ *aggrGroup, step *config.EscalationStep, _ bool, alerts ...*model.Alert) {
+			tg.notify <- notification{step: step, alerts: alerts}
+		}, func(*aggrGroup) {})
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// Wait for the group wait timer.
+	tg.clock.WaitTimers(1)
+
+	return tg
+}
+
+func (tg *testGroup) insert(fingerprints ...string) {
+	for _, fp := range fingerprints {
+		tg.group.insert(&model.Alert{Fingerprint: fp, Text: fp, Severity: model.SeverityWarning})
+	}
+}
+
+// advance moves the clock forward and returns fingerprints of notified alerts,
+// nil if the group did not notify.
+func (tg *testGroup) advance(d time.Duration) (*config.EscalationStep, []string) {
+	tg.clock.Advance(d)
+
+	// The group resets the timer after handling it, so the notification is sent by then.
+	tg.clock.WaitTimers(1)
+	select {
+	case n := <-tg.notify:
+		fps := make([]string, 0, len(n.alerts))
+		for _, a := range n.alerts {
+			fps = append(fps, a.Fingerprint)
+		}
+
+		return n.step, fps
+	default:
+		return nil, nil
+	}
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	c := newFakeClock()
+	timer := c.NewTimer(time.Minute)
+
+	c.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before deadline")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("timer not fired at deadline")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Reset of fired timer reports it active")
+	}
+
+	if !timer.Stop() {
+		t.Error("Stop of reset timer reports it inactive")
+	}
+
+	c.Advance(time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+}
@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+const deadLetterFolder = "dead_letter"
+
+// DeadLetterEntry is an alert group that could not be delivered by notifier.
+type DeadLetterEntry struct {
+	ID        string            `json:"id"`
+	Notifier  string            `json:"notifier"`
+	Technical *config.Technical `json:"technical"`
+	Alerts    []*model.Alert    `json:"alerts"`
+	Error     string            `json:"error"`
+	Attempts  int               `json:"attempts"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// DeadLetter stores undelivered alert groups as JSON files, one file per entry.
+type DeadLetter struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewDeadLetter(sessionDir string) (*DeadLetter, error) {
+	dir := filepath.Join(sessionDir, deadLetterFolder)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &DeadLetter{dir: dir}, nil
+}
+
+func (d *DeadLetter) Put(entry *DeadLetterEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Write to temporary file first, so readers never see partially written entry.
+	tmp := d.path(entry.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, d.path(entry.ID))
+}
+
+func (d *DeadLetter) Get(id string) (*DeadLetterEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.read(d.path(id))
+}
+
+// List returns all stored entries ordered by creation time.
+func (d *DeadLetter) List() ([]*DeadLetterEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(d.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*DeadLetterEntry, 0, len(files))
+	for _, f := range files {
+		entry, err := d.read(f)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+
+	return entries, nil
+}
+
+func (d *DeadLetter) Delete(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := os.Remove(d.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (d *DeadLetter) read(path string) (*DeadLetterEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry DeadLetterEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("read dead letter %s: %w", filepath.Base(path), err)
+	}
+
+	return &entry, nil
+}
+
+func (d *DeadLetter) path(id string) string {
+	// Entry ID comes from CLI arguments as well, do not let it escape the directory.
+	return filepath.Join(d.dir, filepath.Base(id)+".json")
+}
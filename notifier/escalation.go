@@ -0,0 +1,94 @@
+package notifier
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kirychukyurii/notificator/config"
+)
+
+// escalation tracks the current step of the escalation policy for the alert group.
+// It does not read the clock itself, current time is passed by the caller.
+type escalation struct {
+	policy *config.EscalationPolicy
+	level  int
+	since  time.Time
+}
+
+func newEscalation(policy *config.EscalationPolicy) *escalation {
+	if policy == nil || len(policy.Steps) == 0 {
+		return nil
+	}
+
+	return &escalation{policy: policy}
+}
+
+// step returns the current step of the escalation.
+func (e *escalation) step() *config.EscalationStep {
+	return e.policy.Steps[e.level]
+}
+
+// start begins the escalation from the first step if it is not started yet.
+func (e *escalation) start(now time.Time) {
+	if e.since.IsZero() {
+		e.since = now
+	}
+}
+
+// reset returns the escalation to the first step, e.g. after acknowledgement.
+func (e *escalation) reset() {
+	e.level = 0
+	e.since = time.Time{}
+}
+
+// timeout returns how long the current step waits for acknowledgement.
+func (e *escalation) timeout() time.Duration {
+	return e.step().Timeout
+}
+
+// exhausted reports whether the last step is reached.
+func (e *escalation) exhausted() bool {
+	return e.level >= len(e.policy.Steps)-1
+}
+
+// escalate moves to the next step if the current one timed out. It returns
+// false if the escalation is not started, not timed out yet or the last step is reached.
+func (e *escalation) escalate(now time.Time) bool {
+	if e.since.IsZero() || e.exhausted() {
+		return false
+	}
+
+	if timeout := e.timeout(); timeout == 0 || now.Sub(e.since) < timeout {
+		return false
+	}
+
+	e.level++
+	e.since = now
+
+	return true
+}
+
+// validateEscalation checks that technicals and notifiers of the policy steps exist.
+func validateEscalation(cfg *config.Config, policy *config.EscalationPolicy, notifiers []Notifier) error {
+	names := make([]string, 0, len(notifiers))
+	for _, n := range notifiers {
+		names = append(names, n.String())
+	}
+
+	for i, step := range policy.Steps {
+		if step.Technical != "" {
+			if _, ok := cfg.Technical(step.Technical); !ok {
+				return fmt.Errorf("escalation %s step %d: technical %s not found", policy.Name, i, step.Technical)
+			}
+		}
+
+		for _, n := range step.Notifiers {
+			if !slices.Contains(names, n) {
+				return fmt.Errorf("escalation %s step %d: notifier %s not found", policy.Name, i, n)
+			}
+		}
+	}
+
+	return nil
+}
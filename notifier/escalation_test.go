@@ -0,0 +1,99 @@
+package notifier
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kirychukyurii/notificator/config"
+)
+
+func TestGroupAckTimeout(t *testing.T) {
+	tg := newTestGroup(t, &GroupOptions{
+		Wait:       10 * time.Second,
+		Interval:   time.Minute,
+		AckTimeout: 20 * time.Second,
+	})
+
+	tg.insert("a")
+	if _, got := tg.advance(10 * time.Second); !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("after group wait notified %v, want [a]", got)
+	}
+
+	if _, got := tg.advance(10 * time.Second); got != nil {
+		t.Fatalf("before ack timeout notified %v, want none", got)
+	}
+
+	if _, got := tg.advance(10 * time.Second); !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("after ack timeout notified %v, want [a]", got)
+	}
+
+	tg.group.acknowledge()
+	if _, got := tg.advance(20 * time.Second); got != nil {
+		t.Fatalf("acknowledged group notified %v, want none", got)
+	}
+
+	// New alerts after acknowledgement are notified and wait for acknowledgement again.
+	tg.insert("b")
+	if _, got := tg.advance(20 * time.Second); !slices.Equal(got, []string{"b"}) {
+		t.Fatalf("after new alert notified %v, want [b]", got)
+	}
+
+	if _, got := tg.advance(20 * time.Second); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("after ack timeout notified %v, want [a b]", got)
+	}
+}
+
+func TestGroupEscalation(t *testing.T) {
+	policy := &config.EscalationPolicy{
+		Name: "night",
+		Steps: []*config.EscalationStep{
+			{Technical: "first", Timeout: 30 * time.Second},
+			{Technical: "second", Timeout: time.Minute},
+			{Technical: "third"},
+		},
+	}
+
+	tg := newTestGroup(t, &GroupOptions{
+		Wait:       10 * time.Second,
+		Interval:   5 * time.Minute,
+		Escalation: policy,
+	})
+
+	steps := []struct {
+		advance   time.Duration
+		technical string
+	}{
+		{advance: 10 * time.Second, technical: "first"},
+		{advance: 20 * time.Second},
+		{advance: 10 * time.Second, technical: "second"},
+		{advance: 30 * time.Second},
+		{advance: 30 * time.Second, technical: "third"},
+
+		// The last step is never escalated.
+		{advance: 5 * time.Minute},
+	}
+
+	tg.insert("a")
+	for i, s := range steps {
+		step, got := tg.advance(s.advance)
+		if s.technical == "" {
+			if got != nil {
+				t.Fatalf("step %d: notified %v, want none", i, got)
+			}
+
+			continue
+		}
+
+		if !slices.Equal(got, []string{"a"}) || step == nil || step.Technical != s.technical {
+			t.Fatalf("step %d: notified %v with step %+v, want [a] to %s", i, got, step, s.technical)
+		}
+	}
+
+	// Acknowledgement stops the escalation, new alerts start it from the first step.
+	tg.group.acknowledge()
+	tg.insert("b")
+	if step, got := tg.advance(5 * time.Minute); !slices.Equal(got, []string{"b"}) || step.Technical != "first" {
+		t.Fatalf("after acknowledgement notified %v with step %+v, want [b] to first", got, step)
+	}
+}
@@ -0,0 +1,444 @@
+package notifier
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+var (
+	defaultGroupBy       = []string{"channel"}
+	defaultGroupInterval = 30 * time.Second
+)
+
+// GroupOptions defines how alerts are grouped and how often groups are notified.
+type GroupOptions struct {
+	By       []string
+	Wait     time.Duration
+	Interval time.Duration
+	Repeat   time.Duration
+
+	// AckTimeout is how long to wait for acknowledgement before notifying
+	// about the group again. Zero disables re-notification.
+	AckTimeout time.Duration
+
+	// Escalation is the policy applied when the group is not acknowledged in time.
+	Escalation *config.EscalationPolicy
+}
+
+func NewGroupOptions(cfg *config.Config) *GroupOptions {
+	opts := &GroupOptions{
+		By:       cfg.GroupBy,
+		Wait:     cfg.GroupWait,
+		Interval: cfg.GroupInterval,
+		Repeat:   cfg.RepeatInterval,
+	}
+
+	if cfg.Ack != nil {
+		opts.AckTimeout = cfg.Ack.Timeout
+	}
+
+	if cfg.EscalationPolicy != "" {
+		opts.Escalation, _ = cfg.Escalation(cfg.EscalationPolicy)
+	}
+
+	if len(opts.By) == 0 {
+		opts.By = defaultGroupBy
+	}
+
+	// Follow-up alerts are sent not earlier than the first ones.
+	if opts.Interval == 0 {
+		opts.Interval = opts.Wait
+	}
+
+	if opts.Interval == 0 {
+		opts.Interval = defaultGroupInterval
+	}
+
+	return opts
+}
+
+// Key returns the key of the group the alert belongs to.
+func (o *GroupOptions) Key(alert *model.Alert) string {
+	values := make([]string, 0, len(o.By))
+	for _, by := range o.By {
+		values = append(values, by+"="+alert.Field(by))
+	}
+
+	return strings.Join(values, ",")
+}
+
+// notifyFunc delivers flushed alerts, first is set when the group notifies
+// about them for the first time rather than repeats or escalates.
+type notifyFunc func(ctx context.Context, g *aggrGroup, step *config.EscalationStep, first bool, alerts ...*model.Alert)
+
+// readyFunc reports whether the group has anybody to notify at the escalation step.
+type readyFunc func(g *aggrGroup, step *config.EscalationStep) bool
+
+// aggrGroup aggregates alerts with the same group key and notifies about them
+// after GroupWait, then about following alerts every GroupInterval and re-sends
+// all alerts every RepeatInterval or AckTimeout while the group is not acknowledged,
+// escalating to the next step of the policy when the current one timed out.
+type aggrGroup struct {
+	log   *wlog.Logger
+	key   string
+	route *route
+	opts  *GroupOptions
+	clock Clock
+
+	mu         sync.Mutex
+	alerts     []*model.Alert
+	pending    []*model.Alert
+	repeats    map[*model.Alert]int
+	lastNotify time.Time
+	closed     bool
+
+	// notifications holds IDs of the group deliveries, any of them acknowledges the group.
+	notifications []string
+	acked         bool
+	escalation    *escalation
+
+	// urgent is signaled when a critical alert is inserted, so the group is
+	// flushed immediately without waiting for the timer.
+	urgent chan struct{}
+	done   chan struct{}
+}
+
+func newAggrGroup(log *wlog.Logger, key string, r *route, clock Clock) *aggrGroup {
+	return &aggrGroup{
+		log:        log.With(wlog.String("group", key)),
+		key:        key,
+		route:      r,
+		repeats:    make(map[*model.Alert]int),
+		opts:       r.group,
+		clock:      clock,
+		escalation: newEscalation(r.group.Escalation),
+		urgent:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// insert adds alert to the group. It returns false if the group is already closed
+// and a new one has to be created.
+func (g *aggrGroup) insert(alert *model.Alert) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return false
+	}
+
+	g.alerts = append(g.alerts, alert)
+	g.pending = append(g.pending, alert)
+
+	// New alerts after acknowledgement are escalated from the first step.
+	if g.acked && g.escalation != nil {
+		g.escalation.reset()
+	}
+
+	g.acked = false
+
+	if alert.Severity == model.SeverityCritical {
+		select {
+		case g.urgent <- struct{}{}:
+		default:
+		}
+	}
+
+	return true
+}
+
+// repeat counts a duplicate of the latest group alert with the fingerprint. It
+// returns false if the group is closed or has no such alert.
+func (g *aggrGroup) repeat(fingerprint string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return false
+	}
+
+	for i := len(g.alerts) - 1; i >= 0; i-- {
+		if a := g.alerts[i]; a.Fingerprint == fingerprint {
+			g.repeats[a]++
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolve removes alerts with the fingerprint from the group. It returns removed
+// alerts, those of them which were not notified yet and the current escalation step.
+func (g *aggrGroup) resolve(fingerprint string) ([]*model.Alert, []*model.Alert, *config.EscalationStep) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var resolved, pending []*model.Alert
+
+	var alerts []*model.Alert
+	for _, a := range g.alerts {
+		if a.Fingerprint != fingerprint {
+			alerts = append(alerts, a)
+
+			continue
+		}
+
+		resolved = append(resolved, a)
+		delete(g.repeats, a)
+		if slices.Contains(g.pending, a) {
+			pending = append(pending, a)
+		}
+	}
+
+	// Pending slice may be shared with the notification in progress, so a new one is allocated.
+	g.alerts = alerts
+	g.pending = slices.DeleteFunc(append([]*model.Alert(nil), g.pending...), func(a *model.Alert) bool {
+		return a.Fingerprint == fingerprint
+	})
+
+	var step *config.EscalationStep
+	if g.escalation != nil {
+		step = g.escalation.step()
+	}
+
+	return resolved, pending, step
+}
+
+// addNotification remembers ID of the group delivery.
+func (g *aggrGroup) addNotification(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.notifications = append(g.notifications, id)
+}
+
+// notificationIDs returns IDs of the group deliveries.
+func (g *aggrGroup) notificationIDs() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return append([]string(nil), g.notifications...)
+}
+
+// acknowledge marks the group as acknowledged, so it is not notified again until
+// new alerts arrive.
+func (g *aggrGroup) acknowledge() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.acked = true
+}
+
+// run flushes the group until the context is done, the group is stopped or
+// closed by the onIdle callback. Alerts stay pending while the group is not
+// ready, so neither repeat nor escalation starts until somebody is notified.
+func (g *aggrGroup) run(ctx context.Context, ready readyFunc, notify notifyFunc, onIdle func(*aggrGroup)) {
+	g.log.Info("process first alerts in group, waiting for other", wlog.Any("duration", g.opts.Wait))
+	timer := g.clock.NewTimer(g.opts.Wait)
+	defer timer.Stop()
+
+	for {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case <-g.done:
+			return
+		case now = <-timer.C():
+		case <-g.urgent:
+			g.log.Info("critical alert in group, notify immediately")
+			now = g.clock.Now()
+		}
+
+		if !ready(g, g.step()) {
+			g.log.Debug("nobody to notify, keep alerts pending")
+			timer.Reset(g.tick())
+
+			continue
+		}
+
+		if alerts, step, first := g.flush(now); len(alerts) > 0 {
+			notify(ctx, g, step, first, alerts...)
+		} else if g.isIdle() {
+			onIdle(g)
+		}
+
+		if g.isClosed() {
+			return
+		}
+
+		timer.Reset(g.tick())
+	}
+}
+
+// flush returns alerts to be notified with the current escalation step: pending
+// alerts if any, or all alerts of the group when it is time to escalate or
+// repeat the notification. It reports whether the alerts were pending.
+func (g *aggrGroup) flush(now time.Time) ([]*model.Alert, *config.EscalationStep, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var (
+		alerts []*model.Alert
+		first  bool
+	)
+
+	switch {
+	case len(g.pending) > 0:
+		alerts, first = g.pending, true
+		g.pending = nil
+		if g.escalation != nil {
+			g.escalation.start(now)
+		}
+	case g.acked || len(g.alerts) == 0:
+		return nil, nil, false
+	case g.escalation != nil && g.escalation.escalate(now):
+		g.log.Info("group is not acknowledged in time, escalate", wlog.Int("level", g.escalation.level), wlog.Int("alerts", len(g.alerts)))
+		alerts = append([]*model.Alert(nil), g.alerts...)
+	case g.opts.AckTimeout > 0 && now.Sub(g.lastNotify) >= g.opts.AckTimeout:
+		g.log.Info("group is not acknowledged in time, notify again", wlog.Int("alerts", len(g.alerts)))
+		alerts = append([]*model.Alert(nil), g.alerts...)
+	case g.opts.Repeat > 0 && now.Sub(g.lastNotify) >= g.opts.Repeat:
+		g.log.Info("repeat notification for active group", wlog.Int("alerts", len(g.alerts)))
+		alerts = append([]*model.Alert(nil), g.alerts...)
+	default:
+		return nil, nil, false
+	}
+
+	g.lastNotify = now
+
+	var step *config.EscalationStep
+	if g.escalation != nil {
+		step = g.escalation.step()
+	}
+
+	return alerts, step, first
+}
+
+// step returns the current escalation step, nil if the group is not escalated.
+func (g *aggrGroup) step() *config.EscalationStep {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.escalation == nil {
+		return nil
+	}
+
+	return g.escalation.step()
+}
+
+// requeue returns flushed alerts which could not be notified to pending. It
+// returns alerts resolved meanwhile, which are dropped instead.
+func (g *aggrGroup) requeue(alerts []*model.Alert) []*model.Alert {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var dropped []*model.Alert
+	for _, a := range alerts {
+		if !slices.Contains(g.alerts, a) {
+			dropped = append(dropped, a)
+
+			continue
+		}
+
+		if !slices.Contains(g.pending, a) {
+			g.pending = append(g.pending, a)
+		}
+	}
+
+	return dropped
+}
+
+// withRepeats returns alerts with duplicates counted by the group. Alerts may be
+// shared with groups of other routes, so counted alerts are copied.
+func (g *aggrGroup) withRepeats(alerts []*model.Alert) []*model.Alert {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	out := make([]*model.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		if n := g.repeats[a]; n > 0 {
+			c := *a
+			c.Repeats = n
+			a = &c
+		}
+
+		out = append(out, a)
+	}
+
+	return out
+}
+
+// isIdle reports whether the group has nothing to notify about anymore.
+func (g *aggrGroup) isIdle() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// All alerts of the group are resolved.
+	if g.acked || len(g.alerts) == 0 {
+		return true
+	}
+
+	escalating := g.escalation != nil && !g.escalation.exhausted()
+
+	return g.opts.Repeat == 0 && g.opts.AckTimeout == 0 && !escalating
+}
+
+// tick returns the duration until the group is checked again.
+func (g *aggrGroup) tick() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tick := g.opts.Interval
+	if g.opts.AckTimeout > 0 && g.opts.AckTimeout < tick {
+		tick = g.opts.AckTimeout
+	}
+
+	if g.escalation != nil {
+		if timeout := g.escalation.timeout(); timeout > 0 && timeout < tick {
+			tick = timeout
+		}
+	}
+
+	return tick
+}
+
+// closeIfIdle closes the group if there are no alerts waiting for notification.
+func (g *aggrGroup) closeIfIdle() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.pending) > 0 {
+		return false
+	}
+
+	g.closed = true
+
+	return true
+}
+
+func (g *aggrGroup) isClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.closed
+}
+
+func (g *aggrGroup) stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.closed {
+		g.closed = true
+		close(g.done)
+	}
+}
@@ -0,0 +1,214 @@
+package notifier
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+func TestGroupTiming(t *testing.T) {
+	type step struct {
+		insert  []string
+		advance time.Duration
+		want    []string
+	}
+
+	tests := []struct {
+		name  string
+		opts  *GroupOptions
+		steps []step
+	}{
+		{
+			name: "group wait",
+			opts: &GroupOptions{Wait: 30 * time.Second, Interval: time.Minute},
+			steps: []step{
+				{insert: []string{"a"}, advance: 10 * time.Second},
+				{insert: []string{"b"}, advance: 19 * time.Second},
+				{advance: time.Second, want: []string{"a", "b"}},
+			},
+		},
+		{
+			name: "group interval",
+			opts: &GroupOptions{Wait: 30 * time.Second, Interval: time.Minute},
+			steps: []step{
+				{insert: []string{"a"}, advance: 30 * time.Second, want: []string{"a"}},
+				{insert: []string{"b"}, advance: 30 * time.Second},
+				{insert: []string{"c"}, advance: 30 * time.Second, want: []string{"b", "c"}},
+				{advance: time.Minute},
+			},
+		},
+		{
+			name: "repeat interval",
+			opts: &GroupOptions{Wait: 10 * time.Second, Interval: 10 * time.Second, Repeat: 30 * time.Second},
+			steps: []step{
+				{insert: []string{"a"}, advance: 10 * time.Second, want: []string{"a"}},
+				{advance: 10 * time.Second},
+				{advance: 10 * time.Second},
+				{advance: 10 * time.Second, want: []string{"a"}},
+				{insert: []string{"b"}, advance: 10 * time.Second, want: []string{"b"}},
+				{advance: 10 * time.Second},
+				{advance: 10 * time.Second},
+				{advance: 10 * time.Second, want: []string{"a", "b"}},
+			},
+		},
+		{
+			name: "no repeat interval",
+			opts: &GroupOptions{Wait: 10 * time.Second, Interval: 10 * time.Second},
+			steps: []step{
+				{insert: []string{"a"}, advance: 10 * time.Second, want: []string{"a"}},
+				{advance: 10 * time.Second},
+				{advance: time.Hour},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tg := newTestGroup(t, tt.opts)
+			for i, s := range tt.steps {
+				tg.insert(s.insert...)
+				if _, got := tg.advance(s.advance); !slices.Equal(got, s.want) {
+					t.Fatalf("step %d: notified %v, want %v", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupConcurrentInsertFlush(t *testing.T) {
+	log := wlog.NewLogger(&wlog.LoggerConfiguration{})
+	opts := &GroupOptions{Wait: time.Second, Interval: time.Second, Repeat: time.Second}
+	g := newAggrGroup(log, "test", &route{id: "test", group: opts}, newFakeClock())
+
+	const (
+		writers   = 4
+		perWriter = 100
+	)
+
+	var (
+		wg       sync.WaitGroup
+		notified = make(map[string]bool)
+	)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				fp := fmt.Sprintf("%d-%d", w, i)
+				if !g.insert(&model.Alert{Fingerprint: fp}) {
+					t.Errorf("insert %s into open group failed", fp)
+				}
+
+				g.repeat(fp)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+
+		now := time.Now()
+		for {
+			now = now.Add(time.Second)
+			alerts, _, _ := g.flush(now)
+			for _, a := range alerts {
+				notified[a.Fingerprint] = true
+			}
+
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	<-flushed
+
+	// Alerts inserted after the last flush are still pending.
+	alerts, _, _ := g.flush(time.Now().Add(time.Hour))
+	for _, a := range alerts {
+		notified[a.Fingerprint] = true
+	}
+
+	if len(notified) != writers*perWriter {
+		t.Errorf("notified %d alerts, want %d", len(notified), writers*perWriter)
+	}
+}
+
+func TestGroupRepeatsPerGroup(t *testing.T) {
+	log := wlog.NewLogger(&wlog.LoggerConfiguration{})
+	opts := &GroupOptions{Wait: time.Second, Interval: time.Second}
+	first := newAggrGroup(log, "first", &route{id: "first", group: opts}, newFakeClock())
+	second := newAggrGroup(log, "second", &route{id: "second", group: opts}, newFakeClock())
+
+	// Alert matching routes with continue is shared by their groups.
+	alert := &model.Alert{Fingerprint: "a"}
+	first.insert(alert)
+	second.insert(alert)
+	first.repeat("a")
+	first.repeat("a")
+
+	got := first.withRepeats([]*model.Alert{alert})
+	if got[0].Repeats != 2 {
+		t.Errorf("first group repeats %d, want 2", got[0].Repeats)
+	}
+
+	if got := second.withRepeats([]*model.Alert{alert}); got[0].Repeats != 0 {
+		t.Errorf("second group repeats %d, want 0", got[0].Repeats)
+	}
+
+	if alert.Repeats != 0 {
+		t.Errorf("shared alert repeats %d, want 0", alert.Repeats)
+	}
+}
+
+func TestGroupNoOnDuty(t *testing.T) {
+	policy := &config.EscalationPolicy{
+		Name: "night",
+		Steps: []*config.EscalationStep{
+			{Technical: "first", Timeout: 30 * time.Second},
+			{Technical: "second"},
+		},
+	}
+
+	tg := newTestGroup(t, &GroupOptions{
+		Wait:       10 * time.Second,
+		Interval:   time.Minute,
+		Repeat:     time.Minute,
+		Escalation: policy,
+	})
+
+	// Nobody is on duty, alerts stay pending without repeats and escalation.
+	tg.ready.Store(false)
+	tg.insert("a")
+	for i := 0; i < 5; i++ {
+		if _, got := tg.advance(30 * time.Second); got != nil {
+			t.Fatalf("without technical on duty notified %v, want none", got)
+		}
+	}
+
+	// Technical is chosen, alerts are notified once from the first step.
+	tg.ready.Store(true)
+	tg.insert("b")
+	step, got := tg.advance(30 * time.Second)
+	if !slices.Equal(got, []string{"a", "b"}) || step == nil || step.Technical != "first" {
+		t.Fatalf("after technical chosen notified %v with step %+v, want [a b] to first", got, step)
+	}
+
+	if step, got := tg.advance(30 * time.Second); !slices.Equal(got, []string{"a", "b"}) || step.Technical != "second" {
+		t.Fatalf("after step timeout notified %v with step %+v, want [a b] to second", got, step)
+	}
+}
@@ -0,0 +1,188 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// login is the pending login to a listener account: request of a secret or QR code.
+type login struct {
+	account string
+	req     *model.AuthRequest
+	message *telego.Message
+
+	// qr is set when the message is the photo of QR code.
+	qr bool
+}
+
+// processAuthRequest asks the manager for the secret in the manager chat.
+func (q *Queue) processAuthRequest(v *model.AuthRequest) {
+	q.loginsMu.Lock()
+	q.logins[v.ID] = &login{account: v.Account, req: v}
+	q.loginsMu.Unlock()
+
+	if q.bot == nil {
+		q.log.Warn("manager bot disabled, enter login secret in the form", wlog.String("account", v.Account),
+			wlog.String("kind", v.Kind), wlog.String("url", v.FormURL))
+
+		return
+	}
+
+	text := fmt.Sprintf("Account %s requests login %s.\n\nSend /login %s <%s>, the message is deleted once read", v.Account, v.Kind, v.ID, v.Kind)
+	if v.Kind == model.AuthCode {
+		// Telegram expires login codes shared in messages.
+		text += ". Separate digits of the code with spaces, e.g. 1 2 3 4 5"
+	}
+
+	if v.FormURL != "" {
+		text += ".\nOr enter it in the one-time form: " + v.FormURL
+	}
+
+	message, err := q.bot.SendMessage(&telego.SendMessageParams{Text: text})
+	if err != nil {
+		q.log.Error("send login request", wlog.Err(err), wlog.String("account", v.Account))
+
+		return
+	}
+
+	q.loginsMu.Lock()
+	if l, ok := q.logins[v.ID]; ok {
+		l.message = message
+	}
+	q.loginsMu.Unlock()
+}
+
+// processAuthQR sends QR code to the manager chat, expired code of the same
+// login is replaced.
+func (q *Queue) processAuthQR(v *model.AuthQR) {
+	q.loginsMu.Lock()
+	l, ok := q.logins[v.ID]
+	if !ok {
+		l = &login{account: v.Account, qr: true}
+		q.logins[v.ID] = l
+	}
+
+	previous := l.message
+	q.loginsMu.Unlock()
+
+	if q.bot == nil {
+		q.log.Warn("manager bot disabled, scan QR code printed to the terminal", wlog.String("account", v.Account))
+
+		return
+	}
+
+	if previous != nil {
+		if err := q.bot.DeleteMessage(previous.MessageID); err != nil {
+			q.log.Warn("delete expired QR code", wlog.Err(err))
+		}
+	}
+
+	caption := fmt.Sprintf("Account %s requests login. Scan the QR code in Telegram app: Settings > Devices > Link Desktop Device. "+
+		"The code expires at %s", v.Account, v.Expires.Format("15:04:05"))
+	message, err := q.bot.SendPhoto(&telego.SendPhotoParams{
+		Photo:   tu.File(tu.NameReader(bytes.NewReader(v.PNG), "login.png")),
+		Caption: caption,
+	})
+	if err != nil {
+		q.log.Error("send login QR code", wlog.Err(err), wlog.String("account", v.Account))
+
+		return
+	}
+
+	q.loginsMu.Lock()
+	l.message = message
+	q.loginsMu.Unlock()
+}
+
+// processAuthStatus reports progress of the login in the message of its request.
+func (q *Queue) processAuthStatus(v *model.AuthStatus) {
+	q.loginsMu.Lock()
+	l, ok := q.logins[v.ID]
+	if ok && v.Done {
+		delete(q.logins, v.ID)
+	}
+	q.loginsMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	q.log.Info("login status", wlog.String("account", l.account), wlog.String("status", v.Text))
+	if q.bot == nil || l.message == nil {
+		return
+	}
+
+	text := fmt.Sprintf("Account %s login: %s", l.account, v.Text)
+	if !l.qr {
+		params := &telego.EditMessageTextParams{
+			MessageID: l.message.MessageID,
+			Text:      text,
+		}
+
+		if err := q.bot.EditMessage(params); err != nil {
+			q.log.Error("edit login request", wlog.Err(err))
+		}
+
+		return
+	}
+
+	// QR code is not needed anymore, it is replaced by the status.
+	if err := q.bot.DeleteMessage(l.message.MessageID); err != nil {
+		q.log.Warn("delete QR code", wlog.Err(err))
+	}
+
+	message, err := q.bot.SendMessage(&telego.SendMessageParams{Text: text})
+	if err != nil {
+		q.log.Error("send login status", wlog.Err(err))
+
+		return
+	}
+
+	q.loginsMu.Lock()
+	l.message, l.qr = message, false
+	q.loginsMu.Unlock()
+}
+
+// loginCommand passes the secret to the pending login request.
+func (q *Queue) loginCommand(args, by string) (string, error) {
+	id, secret, _ := strings.Cut(strings.TrimSpace(args), " ")
+	secret = strings.TrimSpace(secret)
+	if id == "" || secret == "" {
+		return "", fmt.Errorf("usage: /login <id> <code|password>")
+	}
+
+	q.loginsMu.Lock()
+	l, ok := q.logins[id]
+	q.loginsMu.Unlock()
+	if !ok || l.req == nil {
+		return "", fmt.Errorf("login request %s not found or expired", id)
+	}
+
+	if l.req.Kind == model.AuthCode {
+		secret = strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+
+			return -1
+		}, secret)
+	}
+
+	select {
+	case l.req.Reply <- secret:
+	default:
+		return "", fmt.Errorf("login request %s is already answered", id)
+	}
+
+	q.log.Info("login secret entered", wlog.String("account", l.req.Account), wlog.String("kind", l.req.Kind), wlog.String("by", by))
+
+	return "", nil
+}
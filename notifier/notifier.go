@@ -2,6 +2,8 @@ package notifier
 
 import (
 	"context"
+	"fmt"
+	"slices"
 
 	"github.com/webitel/wlog"
 
@@ -9,6 +11,7 @@ import (
 	"github.com/kirychukyurii/notificator/config/notifiers"
 	"github.com/kirychukyurii/notificator/model"
 	"github.com/kirychukyurii/notificator/notifier/stdout"
+	"github.com/kirychukyurii/notificator/notifier/webhook"
 	"github.com/kirychukyurii/notificator/notifier/webitel"
 )
 
@@ -41,13 +44,47 @@ func NewNotifiers(log *wlog.Logger, nrs *notifiers.Notifiers) ([]Notifier, error
 		add("stdout", "log", func(name string, l *wlog.Logger) (Notifier, error) { return stdout.New(name, l) })
 	}
 
-	// for _, c := range nrs.WebhookConfigs {
-	// 	add("webhook", c.URL, func(l *wlog.Logger) (Notifier, error) { return telegram.New(c, l) })
-	// }
+	names := map[string]bool{"stdout": nrs.StdOut}
+	for _, c := range nrs.WebhookConfigs {
+		name := notifierName(c.Name, "webhook")
+		if names[name] {
+			return nil, fmt.Errorf("duplicate notifier name %q, set unique name", name)
+		}
+
+		names[name] = true
+		add(name, c.URL, func(name string, l *wlog.Logger) (Notifier, error) { return webhook.New(name, c, l) })
+	}
 
 	for _, c := range nrs.WebitelConfigs {
-		add("webitel", c.URL, func(name string, l *wlog.Logger) (Notifier, error) { return webitel.New(name, c, l) })
+		name := notifierName(c.Name, "webitel")
+		if names[name] {
+			return nil, fmt.Errorf("duplicate notifier name %q, set unique name", name)
+		}
+
+		names[name] = true
+		add(name, c.URL, func(name string, l *wlog.Logger) (Notifier, error) { return webitel.New(name, c, l) })
 	}
 
 	return notifiers, nil
 }
+
+// notifierName returns the configured name of the notifier or its kind if empty.
+func notifierName(name, kind string) string {
+	if name == "" {
+		return kind
+	}
+
+	return name
+}
+
+// filterNotifiers returns notifiers with the given names.
+func filterNotifiers(notifiers []Notifier, names []string) []Notifier {
+	filtered := make([]Notifier, 0, len(names))
+	for _, n := range notifiers {
+		if slices.Contains(names, n.String()) {
+			filtered = append(filtered, n)
+		}
+	}
+
+	return filtered
+}
@@ -2,16 +2,18 @@ package notifier
 
 import (
 	"context"
+	"fmt"
 	"sync"
-	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/mymmrac/telego"
 	"github.com/webitel/wlog"
 
 	"github.com/kirychukyurii/notificator/config"
 	"github.com/kirychukyurii/notificator/manager"
 	"github.com/kirychukyurii/notificator/model"
+	"github.com/kirychukyurii/notificator/server"
 )
 
 type cache map[string]any // TODO
@@ -22,126 +24,630 @@ type Message struct {
 }
 
 type Queue struct {
-	log       *wlog.Logger
-	notifiers []Notifier
-	bot       *manager.Bot
-	cache     cache
+	cfg        *config.Config
+	log        *wlog.Logger
+	notifiers  []Notifier
+	bot        *manager.Bot
+	cache      cache
+	retry      *config.Retry
+	deadLetter *DeadLetter
+	silences   *Silences
+	wal        *wal
+	apiToken   string
 
-	wait       time.Duration
-	items      chan *Message
-	processing atomic.Bool
-	mu         *sync.Mutex
+	clock Clock
+	route *route
+	info  *route
 
-	onduty *config.Technical
+	// dedup configures fingerprints of alerts, fingerprints holds the time
+	// each fingerprint was first seen within the dedup window.
+	dedup        *config.Dedup
+	fingerprints map[string]time.Time
+	items        chan *Message
+	groups       map[string]*aggrGroup
+
+	// mu guards sending to items, closed is set by Stop so nothing is sent
+	// to the closed channel.
+	mu     *sync.Mutex
+	closed bool
+
+	// groupsMu guards groups and acknowledgements, so alert is never inserted
+	// into a group which is being closed.
+	groupsMu    sync.Mutex
+	ack         *config.Ack
+	ackURL      string
+	acks        map[string]*aggrGroup
+	ackTokens   map[string]string
+	ackMessages map[string]*telego.Message
+
+	// stateMu guards on duty technical, alerts held until technical is chosen,
+	// write-ahead log sequence numbers of alerts in the queue and the number
+	// of groups which did not handle each alert yet.
+	stateMu sync.Mutex
+	onduty  *config.Technical
+	held    []*model.Alert
+	seqs    map[*model.Alert]uint64
+	refs    map[*model.Alert]int
+
+	// logins holds requests of secrets to log in to listener accounts by ID.
+	loginsMu sync.Mutex
+	logins   map[string]*login
 }
 
-func NewQueue(log *wlog.Logger, wait time.Duration, notifiers []Notifier, bot *manager.Bot) *Queue {
-	return &Queue{
-		log:        log,
-		notifiers:  notifiers,
-		bot:        bot,
-		cache:      make(cache),
-		wait:       wait,
-		items:      make(chan *Message),
-		processing: atomic.Bool{},
-		mu:         &sync.Mutex{},
+func NewQueue(log *wlog.Logger, cfg *config.Config, notifiers []Notifier, bot *manager.Bot, srv *server.Server) (*Queue, error) {
+	deadLetter, err := NewDeadLetter(cfg.SessionsDir)
+	if err != nil {
+		return nil, fmt.Errorf("dead letter: %v", err)
 	}
+
+	silences, err := NewSilences(cfg.SessionsDir)
+	if err != nil {
+		return nil, fmt.Errorf("silences: %v", err)
+	}
+
+	w, err := newWAL(cfg.SessionsDir)
+	if err != nil {
+		return nil, fmt.Errorf("write-ahead log: %v", err)
+	}
+
+	retry := cfg.Retry
+	if retry == nil {
+		retry = &config.DefaultRetry
+	}
+
+	root, err := newRootRoute(cfg, notifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := newInfoRoute(cfg, root, notifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	dedup := cfg.Dedup
+	if dedup == nil {
+		// Fingerprints are calculated anyway, but duplicates are not suppressed.
+		dedup = &config.Dedup{Fields: config.DefaultDedup.Fields}
+	}
+
+	q := &Queue{
+		cfg:          cfg,
+		log:          log,
+		notifiers:    notifiers,
+		bot:          bot,
+		cache:        make(cache),
+		retry:        retry,
+		deadLetter:   deadLetter,
+		silences:     silences,
+		apiToken:     srv.APIToken(),
+		wal:          w,
+		seqs:         make(map[*model.Alert]uint64),
+		refs:         make(map[*model.Alert]int),
+		clock:        RealClock{},
+		route:        root,
+		info:         info,
+		dedup:        dedup,
+		fingerprints: make(map[string]time.Time),
+		items:        make(chan *Message),
+		mu:           &sync.Mutex{},
+		groups:       make(map[string]*aggrGroup),
+		ack:          cfg.Ack,
+		acks:         make(map[string]*aggrGroup),
+		ackTokens:    make(map[string]string),
+		ackMessages:  make(map[string]*telego.Message),
+		logins:       make(map[string]*login),
+	}
+
+	// Silences API mutes alerts, so it is exposed only if protected with the token.
+	if q.apiToken != "" {
+		srv.HandleFunc("/silences", q.handleSilences)
+		srv.HandleFunc("/silences/{id}", q.handleSilence)
+	} else {
+		log.Warn("api token is not set, silences API is disabled")
+	}
+	if bot != nil {
+		bot.HandleCommand("silence", q.silenceCommand)
+		bot.HandleCommand("silences", q.silencesCommand)
+		bot.HandleCommand("unsilence", q.unsilenceCommand)
+		bot.HandleSecretCommand("login", q.loginCommand)
+	}
+
+	if q.ack != nil {
+		q.ackURL = srv.PublicURL() + "/ack/"
+		srv.HandleFunc("POST /ack/{id}", q.handleAck)
+		if bot != nil {
+			bot.HandleAck(q.Acknowledge)
+		}
+	}
+
+	return q, nil
 }
 
 func (q *Queue) Push(v *Message) {
 	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		q.log.Warn("queue is stopped, drop message", wlog.String("channel", v.Channel))
+
+		return
+	}
+
 	q.log.Debug("push alert to queue", wlog.String("channel", v.Channel))
+	if alert, ok := v.Content.(*model.Alert); ok {
+		if alert.Severity == "" {
+			alert.Severity = model.SeverityWarning
+		}
+
+		if alert.Status == "" {
+			alert.Status = model.StatusFiring
+		}
+
+		if alert.Fingerprint == "" {
+			alert.Fingerprint = model.Fingerprint(alert, q.dedup.Fields, q.dedup.IgnoreNumbers)
+		}
+
+		// Persist alert before it gets to the queue, so it is not lost on crash.
+		seq, err := q.wal.append(alert)
+		if err != nil {
+			q.log.Error("write alert to write-ahead log", wlog.Err(err), wlog.String("channel", v.Channel))
+		} else {
+			q.track(alert, seq)
+		}
+	}
+
 	q.items <- v
-	q.mu.Unlock()
+}
+
+// send passes the message to Process unless the queue is stopped.
+func (q *Queue) send(v *Message) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+
+	q.items <- v
+
+	return true
 }
 
 func (q *Queue) WithOnDuty(onduty *config.Technical) {
+	q.stateMu.Lock()
 	q.onduty = onduty
+	held := q.held
+	q.held = nil
+	q.stateMu.Unlock()
+
+	if onduty == nil || len(held) == 0 {
+		return
+	}
+
+	q.log.Info("process alerts received before technical was chosen", wlog.Int("alerts", len(held)))
+	go func() {
+		for _, alert := range held {
+			if !q.send(&Message{Channel: alert.Channel, Content: alert}) {
+				return
+			}
+		}
+	}()
+}
+
+func (q *Queue) OnDuty() *config.Technical {
+	q.stateMu.Lock()
+	defer q.stateMu.Unlock()
+
+	return q.onduty
 }
 
 func (q *Queue) Process(ctx context.Context) {
-	alerts := make([]*model.Alert, 0)
+	// Replay alerts which were not delivered before restart.
+	if pending := q.wal.pending(); len(pending) > 0 {
+		q.log.Info("replay alerts from write-ahead log", wlog.Int("alerts", len(pending)))
+		go func() {
+			for _, r := range pending {
+				q.track(r.Alert, r.Seq)
+				if !q.send(&Message{Channel: r.Alert.Channel, Content: r.Alert}) {
+					return
+				}
+			}
+		}()
+	}
+
 	for item := range q.items {
 		switch v := item.Content.(type) {
 		case *model.AuthCodeURL:
-			if item.Channel == "auth_code_url" {
-				ib := telego.InlineKeyboardMarkup{
-					InlineKeyboard: [][]telego.InlineKeyboardButton{
-						{
-							{
-								Text: "Login",
-								URL:  v.URL,
-							},
-						},
+			q.processAuthCodeURL(item.Channel, v)
+		case *model.AuthRequest:
+			q.processAuthRequest(v)
+		case *model.AuthStatus:
+			q.processAuthStatus(v)
+		case *model.AuthQR:
+			q.processAuthQR(v)
+		case *model.Alert:
+			if v.Status == model.StatusResolved {
+				q.resolve(ctx, v)
+
+				continue
+			}
+
+			if q.OnDuty() == nil {
+				q.log.Warn("no technical on duty, hold alert until chosen", wlog.String("channel", v.Channel))
+				q.hold(v)
+
+				continue
+			}
+
+			q.insert(ctx, v)
+		}
+	}
+}
+
+func (q *Queue) processAuthCodeURL(channel string, v *model.AuthCodeURL) {
+	if channel == "auth_code_url" {
+		ib := telego.InlineKeyboardMarkup{
+			InlineKeyboard: [][]telego.InlineKeyboardButton{
+				{
+					{
+						Text: "Login",
+						URL:  v.URL,
 					},
-				}
+				},
+			},
+		}
 
-				mp := &telego.SendMessageParams{
-					Text:        "Please, login to account using your browser",
-					ReplyMarkup: &ib,
-				}
+		mp := &telego.SendMessageParams{
+			Text:        "Please, login to account using your browser",
+			ReplyMarkup: &ib,
+		}
 
-				message, err := q.bot.SendMessage(mp)
-				if err != nil {
-					q.log.Error("send message", wlog.Err(err))
+		message, err := q.bot.SendMessage(mp)
+		if err != nil {
+			q.log.Error("send message", wlog.Err(err))
 
-					return
-				}
+			return
+		}
 
-				q.cache[v.URL] = message
+		q.cache[v.URL] = message
+	}
+
+	if channel == "resolve_auth_code_url" {
+		if m, ok := q.cache[v.URL]; ok {
+			mp := &telego.EditMessageTextParams{
+				MessageID: m.(*telego.Message).MessageID,
+				Text:      "Successfully logged in",
 			}
 
-			if item.Channel == "resolve_auth_code_url" {
-				if m, ok := q.cache[v.URL]; ok {
-					mp := &telego.EditMessageTextParams{
-						MessageID: m.(*telego.Message).MessageID,
-						Text:      "Successfully logged in",
-					}
+			if err := q.bot.EditMessage(mp); err != nil {
+				q.log.Error("edit message", wlog.Err(err))
+			}
+		}
+	}
+}
 
-					if err := q.bot.EditMessage(mp); err != nil {
-						q.log.Error("edit message", wlog.Err(err))
+// insert adds alert to aggregation groups of the routes it matches, starting
+// new groups if needed.
+func (q *Queue) insert(ctx context.Context, alert *model.Alert) {
+	q.groupsMu.Lock()
+	defer q.groupsMu.Unlock()
 
-					}
-				}
-			}
+	routes := []*route{q.info}
+	if alert.Severity != model.SeverityInfo {
+		routes = q.route.route(alert)
+	}
 
-		case *model.Alert:
-			if q.onduty != nil {
-				alerts = append(alerts, v)
-				if !q.processing.Load() {
-					q.processing.Store(true)
-					go func() {
-						q.log.Info("process first alerts in group, waiting for other", wlog.Any("duration", q.wait))
-						alerts = q.Notify(ctx, q.onduty, alerts...)
-
-						ticker := time.NewTicker(q.wait)
-						defer ticker.Stop()
-
-						select {
-						case <-ticker.C:
-							q.log.Info("stop group wait, listening to new alert")
-							q.processing.Store(false)
-						}
-					}()
-				}
+	if q.suppress(alert, routes) {
+		q.log.Debug("suppress duplicate alert", wlog.String("fingerprint", alert.Fingerprint), wlog.String("channel", alert.Channel))
+		q.release(alert)
+
+		return
+	}
+
+	// Groups may notify before the loop ends, so all of them are counted upfront.
+	q.retain(alert, len(routes))
+	for _, r := range routes {
+		key := r.key(alert)
+		if g, ok := q.groups[key]; ok && g.insert(alert) {
+			continue
+		}
+
+		g := newAggrGroup(q.log, key, r, q.clock)
+		g.insert(alert)
+		q.groups[key] = g
+
+		go g.run(ctx, q.ready, q.notifyGroup, q.closeIdleGroup)
+	}
+}
+
+// suppress counts the alert as a repeat in groups holding an alert with the same
+// fingerprint received within the dedup window. Caller must hold groupsMu.
+func (q *Queue) suppress(alert *model.Alert, routes []*route) bool {
+	if q.dedup.Window <= 0 {
+		return false
+	}
+
+	now := q.clock.Now()
+	for fp, first := range q.fingerprints {
+		if now.Sub(first) >= q.dedup.Window {
+			delete(q.fingerprints, fp)
+		}
+	}
+
+	if _, ok := q.fingerprints[alert.Fingerprint]; !ok {
+		q.fingerprints[alert.Fingerprint] = now
+
+		return false
+	}
+
+	var counted bool
+	for _, r := range routes {
+		if g, ok := q.groups[r.key(alert)]; ok && g.repeat(alert.Fingerprint) {
+			counted = true
+		}
+	}
+
+	// The group of the first alert is already closed, notify about this one again.
+	if !counted {
+		q.fingerprints[alert.Fingerprint] = now
+	}
+
+	return counted
+}
+
+// ready reports whether the group has a technical to notify at the escalation step.
+func (q *Queue) ready(g *aggrGroup, step *config.EscalationStep) bool {
+	technicals, _ := q.recipients(g, step)
+
+	return len(technicals) > 0
+}
+
+// notifyGroup sends alerts to notifiers and technicals of the group route,
+// the escalation step overrides them if set. Alerts notified by the group for
+// the first time are released once delivered or silenced.
+func (q *Queue) notifyGroup(ctx context.Context, g *aggrGroup, step *config.EscalationStep, first bool, alerts ...*model.Alert) {
+	technicals, notifiers := q.recipients(g, step)
+	if len(technicals) == 0 {
+		q.log.Warn("no technical on duty, keep alerts pending", wlog.Int("alerts", len(alerts)))
+		if first {
+			// Group was ready when flushed, so it is notified on the next check.
+			q.release(g.requeue(alerts)...)
+		}
+
+		return
+	}
+
+	if first {
+		defer q.release(alerts...)
+	}
+
+	if alerts = q.unsilenced(alerts); len(alerts) == 0 {
+		return
+	}
+
+	n := &model.Notification{
+		ID: uuid.New().String(),
+	}
+
+	// Info alerts are not acknowledged.
+	ack := q.ack != nil && g.route != q.info
+	if ack {
+		// Token keeps the URL from being acknowledged by guessing the ID.
+		token := uuid.New().String()
+		n.AckURL = q.ackURL + n.ID + "?token=" + token
+		q.groupsMu.Lock()
+		q.acks[n.ID] = g
+		q.ackTokens[n.ID] = token
+		q.groupsMu.Unlock()
+		g.addNotification(n.ID)
+	}
+
+	counted := g.withRepeats(alerts)
+	q.notifyAll(model.WithNotification(ctx, n), notifiers, technicals, counted...)
+	if ack {
+		q.requestAck(n, technicals, counted...)
+	}
+}
+
+// recipients returns technicals and notifiers of the group route, the escalation
+// step overrides them if set. Technicals are empty if nobody is on duty.
+func (q *Queue) recipients(g *aggrGroup, step *config.EscalationStep) ([]*config.Technical, []Notifier) {
+	technicals, notifiers := g.route.technicals, g.route.notifiers
+	if notifiers == nil {
+		notifiers = q.notifiers
+	}
+
+	if step != nil {
+		if step.Technical != "" {
+			// Steps are validated on start, the technical on duty is paged if it is gone anyway.
+			if t, ok := q.cfg.Technical(step.Technical); ok {
+				technicals = []*config.Technical{t}
+			} else {
+				q.log.Error("escalation step technical not found", wlog.String("technical", step.Technical))
+				technicals = nil
 			}
 		}
+
+		if len(step.Notifiers) > 0 {
+			notifiers = filterNotifiers(q.notifiers, step.Notifiers)
+		}
+	}
+
+	if len(technicals) == 0 {
+		if onduty := q.OnDuty(); onduty != nil {
+			technicals = []*config.Technical{onduty}
+		}
+	}
+
+	return technicals, notifiers
+}
+
+func (q *Queue) closeIdleGroup(g *aggrGroup) {
+	q.groupsMu.Lock()
+	defer q.groupsMu.Unlock()
+
+	if g.closeIfIdle() {
+		q.log.Debug("close idle group", wlog.String("group", g.key))
+		if q.groups[g.key] == g {
+			delete(q.groups, g.key)
+		}
+
+		for _, id := range g.notificationIDs() {
+			delete(q.acks, id)
+			delete(q.ackTokens, id)
+			delete(q.ackMessages, id)
+		}
 	}
 }
 
+func (q *Queue) hold(alerts ...*model.Alert) {
+	q.stateMu.Lock()
+	q.held = append(q.held, alerts...)
+	q.stateMu.Unlock()
+}
+
+// Stop closes the queue, messages pushed afterwards are dropped. Alerts which
+// were not replayed yet stay in write-ahead log.
 func (q *Queue) Stop() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+
+		return
+	}
+
+	q.closed = true
 	close(q.items)
+	q.mu.Unlock()
+
+	q.groupsMu.Lock()
+	for key, g := range q.groups {
+		g.stop()
+		delete(q.groups, key)
+	}
+	q.groupsMu.Unlock()
+
+	if err := q.wal.close(); err != nil {
+		q.log.Error("close write-ahead log", wlog.Err(err))
+	}
 }
 
-func (q *Queue) Notify(ctx context.Context, onduty *config.Technical, items ...*model.Alert) []*model.Alert {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-	for _, notifier := range q.notifiers {
-		ok, err := notifier.Notify(ctx, onduty, items...)
-		if err != nil {
-			q.log.Error("send notify message", wlog.Err(err), wlog.Any("retry", ok))
+func (q *Queue) Notify(ctx context.Context, onduty *config.Technical, items ...*model.Alert) {
+	q.notifyAll(ctx, q.notifiers, []*config.Technical{onduty}, items...)
+}
+
+// notifyAll sends alerts to each technical with each notifier.
+func (q *Queue) notifyAll(ctx context.Context, notifiers []Notifier, technicals []*config.Technical, items ...*model.Alert) {
+	wg := &sync.WaitGroup{}
+	for _, technical := range technicals {
+		for _, notifier := range notifiers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				q.notify(ctx, notifier, technical, items...)
+			}()
+		}
+	}
+
+	wg.Wait()
+}
+
+// track remembers write-ahead log sequence number of the alert.
+func (q *Queue) track(alert *model.Alert, seq uint64) {
+	q.stateMu.Lock()
+	q.seqs[alert] = seq
+	q.stateMu.Unlock()
+}
+
+// retain counts groups the alert is inserted into, so it is kept in write-ahead
+// log until all of them handle it.
+func (q *Queue) retain(alert *model.Alert, groups int) {
+	q.stateMu.Lock()
+	q.refs[alert] += groups
+	q.stateMu.Unlock()
+}
+
+// release acknowledges alerts in write-ahead log once they are handled by
+// all groups, i.e. delivered or stored to the dead letter, silenced or resolved.
+func (q *Queue) release(items ...*model.Alert) {
+	seqs := make([]uint64, 0, len(items))
+	q.stateMu.Lock()
+	for _, alert := range items {
+		if q.refs[alert] > 1 {
+			q.refs[alert]--
+
+			continue
+		}
+
+		delete(q.refs, alert)
+		if seq, ok := q.seqs[alert]; ok {
+			seqs = append(seqs, seq)
+			delete(q.seqs, alert)
 		}
 	}
+	q.stateMu.Unlock()
+
+	if err := q.wal.ack(seqs...); err != nil {
+		q.log.Error("acknowledge alerts in write-ahead log", wlog.Err(err))
+	}
+}
+
+// notify sends alerts to the notifier, retrying with exponential backoff while
+// the error is recoverable. Alerts that could not be delivered are stored
+// to the dead letter.
+func (q *Queue) notify(ctx context.Context, notifier Notifier, onduty *config.Technical, items ...*model.Alert) {
+	var (
+		err     error
+		retry   bool
+		attempt int
+		backoff = q.retry.InitialBackoff
+	)
+
+	for attempt = 1; ; attempt++ {
+		retry, err = notifier.Notify(ctx, onduty, items...)
+		if err == nil {
+			return
+		}
+
+		if !retry || attempt >= q.retry.MaxAttempts {
+			break
+		}
+
+		q.log.Warn("send notify message attempt failed", wlog.Err(err), wlog.String("notifier", notifier.String()),
+			wlog.Int("attempt", attempt), wlog.String("retry_in", backoff.String()))
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		backoff *= 2
+		if backoff > q.retry.MaxBackoff {
+			backoff = q.retry.MaxBackoff
+		}
+	}
+
+	q.log.Error("send notify message", wlog.Err(err), wlog.String("notifier", notifier.String()), wlog.Int("attempts", attempt))
+
+	entry := &DeadLetterEntry{
+		Notifier:  notifier.String(),
+		Technical: onduty,
+		Alerts:    items,
+		Error:     err.Error(),
+		Attempts:  attempt,
+	}
+
+	if err := q.deadLetter.Put(entry); err != nil {
+		q.log.Error("store alerts to dead letter", wlog.Err(err), wlog.String("notifier", notifier.String()))
+
+		return
+	}
 
-	return items[:0]
+	q.log.Warn("alerts stored to dead letter", wlog.String("id", entry.ID), wlog.String("notifier", notifier.String()))
 }
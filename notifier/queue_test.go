@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+func TestQueueReleaseSharedAlert(t *testing.T) {
+	w, err := newWAL(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer w.close()
+
+	q := &Queue{
+		wal:  w,
+		seqs: make(map[*model.Alert]uint64),
+		refs: make(map[*model.Alert]int),
+	}
+
+	alert := &model.Alert{Fingerprint: "a"}
+	seq, err := w.append(alert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Alert matches two routes with continue.
+	q.track(alert, seq)
+	q.retain(alert, 2)
+
+	q.release(alert)
+	if _, ok := w.unacked[seq]; !ok {
+		t.Fatal("alert acknowledged after the first of two groups handled it")
+	}
+
+	q.release(alert)
+	if _, ok := w.unacked[seq]; ok {
+		t.Fatal("alert not acknowledged after all groups handled it")
+	}
+}
+
+func TestQueuePushAfterStop(t *testing.T) {
+	w, err := newWAL(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := &Queue{
+		log:    wlog.NewLogger(&wlog.LoggerConfiguration{}),
+		wal:    w,
+		mu:     &sync.Mutex{},
+		items:  make(chan *Message),
+		groups: make(map[string]*aggrGroup),
+		seqs:   make(map[*model.Alert]uint64),
+		refs:   make(map[*model.Alert]int),
+	}
+
+	q.hold(&model.Alert{Fingerprint: "held"})
+	q.Stop()
+
+	// Neither listeners nor the replay of held alerts send to the closed channel.
+	q.WithOnDuty(&config.Technical{Name: "first"})
+	q.Push(&Message{Channel: "test", Content: &model.Alert{Fingerprint: "late"}})
+	q.Stop()
+}
+
+func TestQueueRecipientsUnknownStepTechnical(t *testing.T) {
+	first := &config.Technical{Name: "first"}
+	onduty := &config.Technical{Name: "onduty"}
+
+	q := &Queue{
+		log:    wlog.NewLogger(&wlog.LoggerConfiguration{}),
+		cfg:    &config.Config{Technicals: []*config.Technical{first}},
+		onduty: onduty,
+	}
+
+	g := &aggrGroup{route: &route{id: "test", technicals: []*config.Technical{first}}}
+
+	technicals, _ := q.recipients(g, &config.EscalationStep{Technical: "first"})
+	if len(technicals) != 1 || technicals[0] != first {
+		t.Fatalf("step technicals %v, want [first]", technicals)
+	}
+
+	// Removed technical falls back to the technical on duty instead of a nil one.
+	technicals, _ = q.recipients(g, &config.EscalationStep{Technical: "gone"})
+	if len(technicals) != 1 || technicals[0] != onduty {
+		t.Fatalf("step technicals %v, want [onduty]", technicals)
+	}
+}
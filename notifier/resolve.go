@@ -0,0 +1,69 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// Resolver is implemented by notifiers able to report that alerts were resolved,
+// e.g. to send recovery message or cancel a call which was not made yet.
+type Resolver interface {
+	Resolve(context.Context, *config.Technical, ...*model.Alert) error
+}
+
+// resolve removes firing alerts with the fingerprint of the resolved alert from
+// their groups, so pending escalations are cancelled, and reports the recovery
+// to notifiers of the groups which were already notified.
+func (q *Queue) resolve(ctx context.Context, alert *model.Alert) {
+	defer q.release(alert)
+
+	q.groupsMu.Lock()
+	groups := make([]*aggrGroup, 0, len(q.groups))
+	for _, g := range q.groups {
+		groups = append(groups, g)
+	}
+	q.groupsMu.Unlock()
+
+	var found bool
+	for _, g := range groups {
+		firing, pending, step := g.resolve(alert.Fingerprint)
+		if len(firing) == 0 {
+			continue
+		}
+
+		found = true
+		notified := len(pending) < len(firing)
+		g.log.Info("alerts resolved", wlog.Int("alerts", len(firing)), wlog.Any("notified", notified))
+
+		// Alerts which were not notified by the group yet are handled at this point.
+		q.release(pending...)
+		if notified {
+			go q.notifyResolved(ctx, g, step, alert)
+		}
+	}
+
+	if !found {
+		q.log.Debug("no firing alerts for resolved alert", wlog.String("fingerprint", alert.Fingerprint), wlog.String("channel", alert.Channel))
+	}
+}
+
+// notifyResolved reports the resolved alert to notifiers of the group which support it.
+func (q *Queue) notifyResolved(ctx context.Context, g *aggrGroup, step *config.EscalationStep, alerts ...*model.Alert) {
+	technicals, notifiers := q.recipients(g, step)
+	for _, technical := range technicals {
+		for _, n := range notifiers {
+			r, ok := n.(Resolver)
+			if !ok {
+				continue
+			}
+
+			if err := r.Resolve(ctx, technical, alerts...); err != nil {
+				q.log.Error("send resolve message", wlog.Err(err), wlog.String("notifier", n.String()))
+			}
+		}
+	}
+}
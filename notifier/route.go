@@ -0,0 +1,287 @@
+package notifier
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// routeFields are alert fields routes can match on, besides labels.
+var routeFields = []string{"channel", "chat", "from", "text", "thread", "severity"}
+
+// isRouteField reports whether routes can match on the alert field.
+func isRouteField(name string) bool {
+	if label, ok := strings.CutPrefix(name, model.LabelPrefix); ok {
+		return label != ""
+	}
+
+	return slices.Contains(routeFields, name)
+}
+
+// route is a node of the routing tree resolved against configured notifiers,
+// technicals and escalation policies.
+type route struct {
+	id      string
+	match   map[string]string
+	matchRE map[string]*regexp.Regexp
+
+	// notifiers is nil if all notifiers are used, technicals is nil if the
+	// technical on duty is paged.
+	notifiers  []Notifier
+	technicals []*config.Technical
+	group      *GroupOptions
+
+	cont   bool
+	routes []*route
+}
+
+// newRootRoute builds the routing tree from the configuration, the root route
+// takes grouping settings from the top level of the configuration.
+func newRootRoute(cfg *config.Config, notifiers []Notifier) (*route, error) {
+	root := &route{
+		id:    "root",
+		group: NewGroupOptions(cfg),
+	}
+
+	if cfg.EscalationPolicy != "" {
+		if root.group.Escalation == nil {
+			return nil, fmt.Errorf("escalation policy %s not found", cfg.EscalationPolicy)
+		}
+
+		if err := validateEscalation(cfg, root.group.Escalation, notifiers); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Route == nil {
+		return root, nil
+	}
+
+	// Root route matches all alerts, matchers would be silently ignored.
+	if len(cfg.Route.Match) > 0 || len(cfg.Route.MatchRE) > 0 {
+		return nil, fmt.Errorf("route %s: match and match_re are not allowed", root.id)
+	}
+
+	if err := root.configure(cfg, cfg.Route, notifiers); err != nil {
+		return nil, fmt.Errorf("route %s: %v", root.id, err)
+	}
+
+	return root, nil
+}
+
+func newRoute(cfg *config.Config, rc *config.Route, parent *route, id string, notifiers []Notifier) (*route, error) {
+	group := *parent.group
+	r := &route{
+		id:         id,
+		notifiers:  parent.notifiers,
+		technicals: parent.technicals,
+		group:      &group,
+		cont:       rc.Continue,
+	}
+
+	for field, value := range rc.Match {
+		if !isRouteField(field) {
+			return nil, fmt.Errorf("match: unknown alert field %s", field)
+		}
+
+		if r.match == nil {
+			r.match = make(map[string]string)
+		}
+
+		r.match[field] = value
+	}
+
+	for field, expr := range rc.MatchRE {
+		if !isRouteField(field) {
+			return nil, fmt.Errorf("match_re: unknown alert field %s", field)
+		}
+
+		// Expressions are anchored, so they have to match the whole value.
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("match_re %s: %v", field, err)
+		}
+
+		if r.matchRE == nil {
+			r.matchRE = make(map[string]*regexp.Regexp)
+		}
+
+		r.matchRE[field] = re
+	}
+
+	if err := r.configure(cfg, rc, notifiers); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// configure applies settings of the route and builds its child routes.
+func (r *route) configure(cfg *config.Config, rc *config.Route, notifiers []Notifier) error {
+	if len(rc.Notifiers) > 0 {
+		r.notifiers = filterNotifiers(notifiers, rc.Notifiers)
+		if len(r.notifiers) != len(rc.Notifiers) {
+			return fmt.Errorf("notifiers %v: some of them not found", rc.Notifiers)
+		}
+	}
+
+	switch {
+	case rc.Technical != "" && rc.Team != "":
+		return fmt.Errorf("technical and team are mutually exclusive")
+	case rc.Technical != "":
+		t, ok := cfg.Technical(rc.Technical)
+		if !ok {
+			return fmt.Errorf("technical %s not found", rc.Technical)
+		}
+
+		r.technicals = []*config.Technical{t}
+	case rc.Team != "":
+		team, ok := cfg.Team(rc.Team)
+		if !ok {
+			return fmt.Errorf("team %s not found", rc.Team)
+		}
+
+		r.technicals = nil
+		for _, name := range team.Technicals {
+			t, ok := cfg.Technical(name)
+			if !ok {
+				return fmt.Errorf("team %s: technical %s not found", team.Name, name)
+			}
+
+			r.technicals = append(r.technicals, t)
+		}
+	}
+
+	if len(rc.GroupBy) > 0 {
+		r.group.By = rc.GroupBy
+	}
+
+	if rc.GroupWait > 0 {
+		r.group.Wait = rc.GroupWait
+	}
+
+	if rc.GroupInterval > 0 {
+		r.group.Interval = rc.GroupInterval
+	}
+
+	if rc.RepeatInterval > 0 {
+		r.group.Repeat = rc.RepeatInterval
+	}
+
+	if rc.EscalationPolicy != "" {
+		policy, ok := cfg.Escalation(rc.EscalationPolicy)
+		if !ok {
+			return fmt.Errorf("escalation policy %s not found", rc.EscalationPolicy)
+		}
+
+		if err := validateEscalation(cfg, policy, notifiers); err != nil {
+			return err
+		}
+
+		r.group.Escalation = policy
+	}
+
+	for i, child := range rc.Routes {
+		id := r.id + "." + strconv.Itoa(i)
+		cr, err := newRoute(cfg, child, r, id, notifiers)
+		if err != nil {
+			return fmt.Errorf("route %s: %v", id, err)
+		}
+
+		r.routes = append(r.routes, cr)
+	}
+
+	return nil
+}
+
+// matches reports whether the alert satisfies all matchers of the route.
+func (r *route) matches(alert *model.Alert) bool {
+	for field, value := range r.match {
+		if alert.Field(field) != value {
+			return false
+		}
+	}
+
+	for field, re := range r.matchRE {
+		if !re.MatchString(alert.Field(field)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// route returns the deepest routes matching the alert, the route itself if none
+// of its children matched. Route has to match the alert before calling it.
+func (r *route) route(alert *model.Alert) []*route {
+	var routes []*route
+	for _, child := range r.routes {
+		if !child.matches(alert) {
+			continue
+		}
+
+		routes = append(routes, child.route(alert)...)
+		if !child.cont {
+			break
+		}
+	}
+
+	if len(routes) == 0 {
+		return []*route{r}
+	}
+
+	return routes
+}
+
+// newInfoRoute builds the route for info alerts: they are sent only to the info
+// notifiers, never escalated nor acknowledged, and collected into a digest if
+// the digest interval is set.
+func newInfoRoute(cfg *config.Config, root *route, notifiers []Notifier) (*route, error) {
+	group := NewGroupOptions(cfg)
+	group.AckTimeout = 0
+	group.Escalation = nil
+
+	var names []string
+	if cfg.Severity != nil {
+		names = cfg.Severity.InfoNotifiers
+
+		if cfg.Severity.DigestInterval > 0 {
+			group.By = []string{"severity"}
+			group.Wait = cfg.Severity.DigestInterval
+			group.Interval = cfg.Severity.DigestInterval
+			group.Repeat = 0
+		}
+	}
+
+	r := &route{
+		id:    "info",
+		group: group,
+	}
+
+	// Info alerts are only logged with stdout notifier by default, if it is enabled,
+	// otherwise they are sent to notifiers of the root route rather than dropped.
+	if len(names) == 0 {
+		if r.notifiers = filterNotifiers(notifiers, []string{"stdout"}); len(r.notifiers) == 0 {
+			r.notifiers = root.notifiers
+		}
+
+		return r, nil
+	}
+
+	r.notifiers = filterNotifiers(notifiers, names)
+	if len(r.notifiers) != len(names) {
+		return nil, fmt.Errorf("info notifiers %v: some of them not found", names)
+	}
+
+	return r, nil
+}
+
+// key returns the key of the route group the alert belongs to.
+func (r *route) key(alert *model.Alert) string {
+	return r.id + ":" + r.group.Key(alert)
+}
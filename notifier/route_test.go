@@ -0,0 +1,34 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/kirychukyurii/notificator/config"
+)
+
+func TestRootRouteMatchers(t *testing.T) {
+	tests := []struct {
+		name  string
+		route *config.Route
+	}{
+		{name: "match", route: &config.Route{Match: map[string]string{"channel": "alerts"}}},
+		{name: "match_re", route: &config.Route{MatchRE: map[string]string{"severity": "critical|warning"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newRootRoute(&config.Config{Route: tt.route}, nil); err == nil {
+				t.Fatal("root route with matchers created")
+			}
+		})
+	}
+
+	// Matchers of child routes are allowed.
+	cfg := &config.Config{Route: &config.Route{Routes: []*config.Route{
+		{Match: map[string]string{"channel": "alerts"}},
+	}}}
+
+	if _, err := newRootRoute(cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+}
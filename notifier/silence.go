@@ -0,0 +1,574 @@
+package notifier
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/model"
+)
+
+const silencesFolder = "silences"
+
+// silenceRetention is how long expired silences are kept for the history.
+const silenceRetention = 7 * 24 * time.Hour
+
+var ErrSilenceNotFound = errors.New("silence not found")
+
+// Matcher matches alert field by value or regular expression.
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"is_regex"`
+
+	re *regexp.Regexp
+}
+
+// ParseMatcher parses matcher formatted as field=value or field=~regex.
+func ParseMatcher(s string) (*Matcher, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return nil, fmt.Errorf("matcher %q: expected field=value or field=~regex", s)
+	}
+
+	m := &Matcher{Name: strings.TrimSpace(name)}
+	if strings.HasPrefix(value, "~") {
+		m.IsRegex = true
+		value = value[1:]
+	}
+
+	m.Value = strings.Trim(value, `"`)
+	if err := m.compile(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *Matcher) compile() error {
+	if !isRouteField(m.Name) {
+		return fmt.Errorf("matcher: unknown alert field %s", m.Name)
+	}
+
+	if !m.IsRegex {
+		return nil
+	}
+
+	re, err := regexp.Compile("^(?:" + m.Value + ")$")
+	if err != nil {
+		return fmt.Errorf("matcher %s: %v", m.Name, err)
+	}
+
+	m.re = re
+
+	return nil
+}
+
+func (m *Matcher) matches(alert *model.Alert) bool {
+	if m.IsRegex {
+		return m.re.MatchString(alert.Field(m.Name))
+	}
+
+	return alert.Field(m.Name) == m.Value
+}
+
+func (m *Matcher) String() string {
+	if m.IsRegex {
+		return fmt.Sprintf("%s=~%q", m.Name, m.Value)
+	}
+
+	return fmt.Sprintf("%s=%q", m.Name, m.Value)
+}
+
+// Silence mutes alerts matching all its matchers between StartsAt and EndsAt.
+type Silence struct {
+	ID        string     `json:"id"`
+	Matchers  []*Matcher `json:"matchers"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    time.Time  `json:"ends_at"`
+	CreatedBy string     `json:"created_by"`
+	Comment   string     `json:"comment"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Validate checks the silence and compiles its matchers.
+func (s *Silence) Validate() error {
+	if len(s.Matchers) == 0 {
+		return fmt.Errorf("at least one matcher required")
+	}
+
+	for _, m := range s.Matchers {
+		if err := m.compile(); err != nil {
+			return err
+		}
+	}
+
+	if s.EndsAt.IsZero() || !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("end must be after start")
+	}
+
+	if s.CreatedBy == "" {
+		return fmt.Errorf("author required")
+	}
+
+	return nil
+}
+
+// Active reports whether the silence mutes alerts at the given time.
+func (s *Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// Matches reports whether the alert satisfies all matchers of the silence.
+func (s *Silence) Matches(alert *model.Alert) bool {
+	for _, m := range s.Matchers {
+		if !m.matches(alert) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Silence) String() string {
+	matchers := make([]string, 0, len(s.Matchers))
+	for _, m := range s.Matchers {
+		matchers = append(matchers, m.String())
+	}
+
+	return strings.Join(matchers, ", ")
+}
+
+// Silences stores silences as JSON files, one file per silence, so they can be
+// managed by CLI while the app is running. Silences are cached in memory and
+// reloaded when the directory changes, files are replaced on write, so any
+// change updates the directory.
+type Silences struct {
+	dir string
+	mu  sync.Mutex
+
+	// silences are ordered by start time, nil if not loaded yet.
+	silences []*Silence
+	modTime  time.Time
+}
+
+func NewSilences(sessionDir string) (*Silences, error) {
+	dir := filepath.Join(sessionDir, silencesFolder)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &Silences{dir: dir}, nil
+}
+
+// Put validates and stores the silence, it starts immediately if start is not set.
+func (s *Silences) Put(silence *Silence) error {
+	if silence.ID == "" {
+		silence.ID = uuid.New().String()
+	}
+
+	if silence.CreatedAt.IsZero() {
+		silence.CreatedAt = time.Now()
+	}
+
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = silence.CreatedAt
+	}
+
+	if err := silence.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.write(silence)
+}
+
+func (s *Silences) Get(id string) (*Silence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	for _, silence := range s.silences {
+		if silence.ID == id {
+			return silence, nil
+		}
+	}
+
+	return nil, ErrSilenceNotFound
+}
+
+// List returns all stored silences ordered by start time.
+func (s *Silences) List() ([]*Silence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return append([]*Silence(nil), s.silences...), nil
+}
+
+// Expire ends the silence now, expired silences are kept for the history
+// until the retention passes.
+func (s *Silences) Expire(id string) (*Silence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(s.silences, func(silence *Silence) bool { return silence.ID == id })
+	if i < 0 {
+		return nil, ErrSilenceNotFound
+	}
+
+	// Cached silences are shared with callers, so the expired one is a copy.
+	silence := *s.silences[i]
+	now := time.Now()
+	if !silence.EndsAt.After(now) {
+		return &silence, nil
+	}
+
+	if silence.StartsAt.After(now) {
+		silence.StartsAt = now
+	}
+
+	silence.EndsAt = now
+
+	// Validation requires end after start, so the silence is written as is.
+	if err := s.write(&silence); err != nil {
+		return nil, err
+	}
+
+	return &silence, nil
+}
+
+// Silenced returns the active silence muting the alert, if any.
+func (s *Silences) Silenced(alert *model.Alert, now time.Time) (*Silence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	for _, silence := range s.silences {
+		if silence.Active(now) && silence.Matches(alert) {
+			return silence, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// load reads silences if the directory changed since they were loaded, silences
+// expired longer than the retention ago are removed. Caller must hold mu.
+func (s *Silences) load() error {
+	info, err := os.Stat(s.dir)
+	if err != nil {
+		return err
+	}
+
+	if s.silences != nil && info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	silences := make([]*Silence, 0, len(files))
+	for _, f := range files {
+		silence, err := s.read(f)
+		if err != nil {
+			return err
+		}
+
+		if now.Sub(silence.EndsAt) > silenceRetention {
+			if err := os.Remove(f); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+
+			continue
+		}
+
+		silences = append(silences, silence)
+	}
+
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].StartsAt.Before(silences[j].StartsAt)
+	})
+
+	// Time of the directory before reading is kept, so files written meanwhile are
+	// loaded next time. Removed expired files only cause one more reload.
+	s.silences = silences
+	s.modTime = info.ModTime()
+
+	return nil
+}
+
+// write replaces the file of the silence and invalidates the cache. Caller must hold mu.
+func (s *Silences) write(silence *Silence) error {
+	data, err := json.MarshalIndent(silence, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path(silence.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, s.path(silence.ID)); err != nil {
+		return err
+	}
+
+	s.silences = nil
+
+	return nil
+}
+
+func (s *Silences) read(path string) (*Silence, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var silence Silence
+	if err := json.Unmarshal(data, &silence); err != nil {
+		return nil, fmt.Errorf("read silence %s: %w", filepath.Base(path), err)
+	}
+
+	for _, m := range silence.Matchers {
+		if err := m.compile(); err != nil {
+			return nil, fmt.Errorf("read silence %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	return &silence, nil
+}
+
+func (s *Silences) path(id string) string {
+	// Silence ID comes from API and CLI arguments, do not let it escape the directory.
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
+
+// unsilenced returns alerts not muted by active silences.
+func (q *Queue) unsilenced(alerts []*model.Alert) []*model.Alert {
+	now := q.clock.Now()
+	out := make([]*model.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		silence, err := q.silences.Silenced(a, now)
+		if err != nil {
+			q.log.Error("check silences", wlog.Err(err))
+		}
+
+		if silence != nil {
+			q.log.Info("alert silenced", wlog.String("silence", silence.ID), wlog.String("channel", a.Channel))
+
+			continue
+		}
+
+		out = append(out, a)
+	}
+
+	return out
+}
+
+// handleSilences lists silences on GET and creates a silence from JSON body on POST.
+func (q *Queue) handleSilences(w http.ResponseWriter, r *http.Request) {
+	if !q.authorizeAPI(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		silences, err := q.silences.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		writeJSON(w, http.StatusOK, silences)
+	case http.MethodPost:
+		var silence Silence
+		if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+			http.Error(w, "invalid silence: "+err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		silence.ID = ""
+		silence.CreatedAt = time.Time{}
+		if err := q.silences.Put(&silence); err != nil {
+			http.Error(w, "invalid silence: "+err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		q.log.Info("silence created", wlog.String("id", silence.ID), wlog.String("by", silence.CreatedBy), wlog.String("matchers", silence.String()))
+		writeJSON(w, http.StatusCreated, &silence)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSilence returns the silence on GET and expires it on DELETE.
+func (q *Queue) handleSilence(w http.ResponseWriter, r *http.Request) {
+	if !q.authorizeAPI(w, r) {
+		return
+	}
+
+	var (
+		silence *Silence
+		err     error
+	)
+
+	switch r.Method {
+	case http.MethodGet:
+		silence, err = q.silences.Get(r.PathValue("id"))
+	case http.MethodDelete:
+		if silence, err = q.silences.Expire(r.PathValue("id")); err == nil {
+			q.log.Info("silence expired", wlog.String("id", silence.ID))
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrSilenceNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(w, http.StatusOK, silence)
+	}
+}
+
+// authorizeAPI requires the API token as bearer authorization, management API
+// is not registered without the token, so it is never open.
+func (q *Queue) authorizeAPI(w http.ResponseWriter, r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || q.apiToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(q.apiToken)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// silenceCommand creates silence from the manager bot command formatted as
+// "<duration> <matcher>... [-- comment]", e.g. "2h chat=#db -- maintenance".
+func (q *Queue) silenceCommand(args, by string) (string, error) {
+	args, comment, _ := strings.Cut(args, "--")
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("usage: /silence <duration> <field=value|field=~regex>... [-- comment]")
+	}
+
+	d, err := time.ParseDuration(fields[0])
+	if err != nil {
+		return "", err
+	}
+
+	silence := &Silence{
+		StartsAt:  time.Now(),
+		CreatedBy: by,
+		Comment:   strings.TrimSpace(comment),
+	}
+
+	silence.EndsAt = silence.StartsAt.Add(d)
+	for _, f := range fields[1:] {
+		m, err := ParseMatcher(f)
+		if err != nil {
+			return "", err
+		}
+
+		silence.Matchers = append(silence.Matchers, m)
+	}
+
+	if err := q.silences.Put(silence); err != nil {
+		return "", err
+	}
+
+	q.log.Info("silence created", wlog.String("id", silence.ID), wlog.String("by", by), wlog.String("matchers", silence.String()))
+
+	return fmt.Sprintf("Silence %s created until %s: %s", silence.ID, silence.EndsAt.Format(time.DateTime), silence), nil
+}
+
+// silencesCommand lists active and pending silences.
+func (q *Queue) silencesCommand(_, _ string) (string, error) {
+	silences, err := q.silences.List()
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	now := time.Now()
+	for _, s := range silences {
+		if !s.EndsAt.After(now) {
+			continue
+		}
+
+		fmt.Fprintf(&text, "%s until %s by %s: %s", s.ID, s.EndsAt.Format(time.DateTime), s.CreatedBy, s)
+		if s.Comment != "" {
+			fmt.Fprintf(&text, " (%s)", s.Comment)
+		}
+
+		text.WriteString("\n")
+	}
+
+	if text.Len() == 0 {
+		return "No active silences", nil
+	}
+
+	return text.String(), nil
+}
+
+// unsilenceCommand expires the silence by its ID.
+func (q *Queue) unsilenceCommand(args, by string) (string, error) {
+	if args == "" {
+		return "", fmt.Errorf("usage: /unsilence <id>")
+	}
+
+	s, err := q.silences.Expire(args)
+	if err != nil {
+		return "", err
+	}
+
+	q.log.Info("silence expired", wlog.String("id", s.ID), wlog.String("by", by))
+
+	return fmt.Sprintf("Silence %s expired", s.ID), nil
+}
@@ -24,6 +24,12 @@ func (l *Logger) Notify(ctx context.Context, onduty *config.Technical, alert ...
 	return false, nil
 }
 
+func (l *Logger) Resolve(ctx context.Context, onduty *config.Technical, alert ...*model.Alert) error {
+	l.log.Info("receive resolved alerts", wlog.Any("alerts", alert), wlog.Any("on_duty", onduty))
+
+	return nil
+}
+
 func (l *Logger) String() string {
 	return l.name
 }
@@ -0,0 +1,289 @@
+package notifier
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kirychukyurii/notificator/model"
+)
+
+const (
+	walFolder = "wal"
+
+	// maxSegmentSize is the size after which a new segment file is started.
+	maxSegmentSize = 4 << 20
+)
+
+// walRecord is a single line of a segment file: either an alert pushed to
+// the queue or an acknowledgement that alert with the sequence number was handled.
+type walRecord struct {
+	Seq   uint64       `json:"seq"`
+	Alert *model.Alert `json:"alert,omitempty"`
+	Ack   bool         `json:"ack,omitempty"`
+}
+
+// wal is an append-only write-ahead log of alerts pushed to the queue. Alerts
+// stay in the log until they are acknowledged, so they can be replayed after
+// crash or restart. Log is split into segment files, fully acknowledged
+// segments are removed from the beginning of the log.
+type wal struct {
+	dir string
+	mu  sync.Mutex
+
+	seq     uint64
+	current int
+	file    *os.File
+	size    int64
+
+	// unacked holds segment ID for each unacknowledged sequence number and
+	// segments holds unacknowledged records count per segment.
+	unacked  map[uint64]int
+	segments map[int]int
+	replay   []*walRecord
+}
+
+func newWAL(sessionDir string) (*wal, error) {
+	dir := filepath.Join(sessionDir, walFolder)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	w := &wal{
+		dir:      dir,
+		unacked:  make(map[uint64]int),
+		segments: make(map[int]int),
+	}
+
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+
+	if err := w.rotate(); err != nil {
+		return nil, err
+	}
+
+	if err := w.truncate(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// pending returns alerts which were not acknowledged before the log was opened.
+func (w *wal) pending() []*walRecord {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	replay := w.replay
+	w.replay = nil
+
+	return replay
+}
+
+func (w *wal) append(alert *model.Alert) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.seq++
+	if err := w.write(&walRecord{Seq: w.seq, Alert: alert}); err != nil {
+		return 0, err
+	}
+
+	w.unacked[w.seq] = w.current
+	w.segments[w.current]++
+
+	return w.seq, nil
+}
+
+func (w *wal) ack(seqs ...uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, seq := range seqs {
+		segment, ok := w.unacked[seq]
+		if !ok {
+			continue
+		}
+
+		if err := w.write(&walRecord{Seq: seq, Ack: true}); err != nil {
+			return err
+		}
+
+		delete(w.unacked, seq)
+		w.segments[segment]--
+	}
+
+	return w.truncate()
+}
+
+func (w *wal) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	return w.file.Close()
+}
+
+func (w *wal) write(r *walRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if w.size+int64(len(data))+1 > maxSegmentSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.Write(append(data, '\n'))
+	w.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	return w.file.Sync()
+}
+
+// rotate closes current segment and starts the next one.
+func (w *wal) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+	}
+
+	w.current++
+	f, err := os.OpenFile(w.segmentPath(w.current), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.size = 0
+	w.segments[w.current] = 0
+
+	return nil
+}
+
+// truncate removes fully acknowledged segments from the beginning of the log.
+// Acknowledgements are always written after the records they refer to, so removing
+// only the oldest segments never resurrects acknowledged records on replay.
+// The current segment is rotated only by size, see write.
+func (w *wal) truncate() error {
+	ids := make([]int, 0, len(w.segments))
+	for id := range w.segments {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+	for _, id := range ids {
+		if id == w.current || w.segments[id] > 0 {
+			break
+		}
+
+		if err := os.Remove(w.segmentPath(id)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		delete(w.segments, id)
+	}
+
+	return nil
+}
+
+// load reads all segments and collects records which were not acknowledged.
+func (w *wal) load() error {
+	files, err := filepath.Glob(filepath.Join(w.dir, "*.wal"))
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int, 0, len(files))
+	for _, f := range files {
+		id, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(f), ".wal"))
+		if err != nil {
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+	records := make(map[uint64]*walRecord)
+	for _, id := range ids {
+		if err := w.loadSegment(id, records); err != nil {
+			return err
+		}
+
+		w.current = id
+	}
+
+	for seq, r := range records {
+		w.replay = append(w.replay, r)
+		w.segments[w.unacked[seq]]++
+	}
+
+	sort.Slice(w.replay, func(i, j int) bool {
+		return w.replay[i].Seq < w.replay[j].Seq
+	})
+
+	return nil
+}
+
+func (w *wal) loadSegment(id int, records map[uint64]*walRecord) error {
+	f, err := os.Open(w.segmentPath(id))
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	w.segments[id] = 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxSegmentSize)
+	for scanner.Scan() {
+		var r walRecord
+
+		// The last line may be partially written on crash, skip it.
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+
+		if r.Seq > w.seq {
+			w.seq = r.Seq
+		}
+
+		if r.Ack {
+			delete(records, r.Seq)
+			delete(w.unacked, r.Seq)
+
+			continue
+		}
+
+		if r.Alert != nil {
+			records[r.Seq] = &r
+			w.unacked[r.Seq] = id
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read segment %d: %v", id, err)
+	}
+
+	return nil
+}
+
+func (w *wal) segmentPath(id int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%016d.wal", id))
+}
@@ -0,0 +1,70 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/kirychukyurii/notificator/model"
+)
+
+func TestWALRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := newWAL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Drained log keeps writing to the current segment.
+	current := w.current
+	for i := 0; i < 10; i++ {
+		seq, err := w.append(&model.Alert{Fingerprint: "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.ack(seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if w.current != current {
+		t.Fatalf("segment rotated to %d before reaching size limit, want %d", w.current, current)
+	}
+
+	// Old segment is removed once its records are acknowledged.
+	seq, err := w.append(&model.Alert{Fingerprint: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := w.segments[current]; !ok {
+		t.Fatal("segment with unacknowledged record removed")
+	}
+
+	if err := w.ack(seq); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := w.segments[current]; ok {
+		t.Fatal("fully acknowledged segment is not removed")
+	}
+
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is replayed after restart.
+	w, err = newWAL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer w.close()
+
+	if pending := w.pending(); len(pending) != 0 {
+		t.Fatalf("replayed %d records, want none", len(pending))
+	}
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"text/template"
+
+	"github.com/webitel/wlog"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/config/notifiers"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// Message defines the JSON object send to webhook endpoints.
+type Message struct {
+	Notification *model.Notification `json:"notification,omitempty"`
+	Status       model.Status        `json:"status"`
+	Technical    *config.Technical   `json:"technical"`
+	Alerts       []*model.Alert      `json:"alerts"`
+}
+
+type Webhook struct {
+	name string
+	cfg  *notifiers.WebhookConfig
+	log  *wlog.Logger
+	cli  *Client
+	tmpl *template.Template
+}
+
+func New(name string, cfg *notifiers.WebhookConfig, log *wlog.Logger) (*Webhook, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deliveries are retried by the queue, so the client makes a single attempt.
+	cli, err := NewClient(&Options{
+		URL:                u,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		RetryStatusCodes:   cfg.RetryStatusCodes,
+		ContentType:        cfg.ContentType,
+		Authorization:      cfg.Authorization,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var tmpl *template.Template
+	if cfg.Template != "" {
+		if tmpl, err = parseTemplate(cfg.Template); err != nil {
+			return nil, fmt.Errorf("parse template: %w", err)
+		}
+	}
+
+	return &Webhook{
+		name: name,
+		cfg:  cfg,
+		log:  log,
+		cli:  cli,
+		tmpl: tmpl,
+	}, nil
+}
+
+func (w *Webhook) Notify(ctx context.Context, technical *config.Technical, alert ...*model.Alert) (bool, error) {
+	n, _ := model.NotificationFromContext(ctx)
+	payload, err := w.payload(n, model.StatusFiring, technical, alert...)
+	if err != nil {
+		return false, err
+	}
+
+	if retry, err := w.cli.Request(ctx, http.MethodPost, "", payload, nil); err != nil {
+		return retry, err
+	}
+
+	w.log.Info("send alerts to webhook", wlog.Int("alerts", len(alert)))
+
+	return false, nil
+}
+
+// Resolve sends resolved alerts with the resolved status.
+func (w *Webhook) Resolve(ctx context.Context, technical *config.Technical, alert ...*model.Alert) error {
+	payload, err := w.payload(nil, model.StatusResolved, technical, alert...)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.cli.Request(ctx, http.MethodPost, "", payload, nil); err != nil {
+		return err
+	}
+
+	w.log.Info("send resolved alerts to webhook", wlog.Int("alerts", len(alert)))
+
+	return nil
+}
+
+func (w *Webhook) payload(n *model.Notification, status model.Status, technical *config.Technical, alert ...*model.Alert) ([]byte, error) {
+	if w.tmpl != nil {
+		payload, err := executeTemplate(w.tmpl, n, status, technical, alert...)
+		if err != nil {
+			return nil, fmt.Errorf("execute template: %w", err)
+		}
+
+		return payload, nil
+	}
+
+	return json.Marshal(&Message{
+		Notification: n,
+		Status:       status,
+		Technical:    technical,
+		Alerts:       alert,
+	})
+}
+
+func (w *Webhook) String() string {
+	return w.name
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kirychukyurii/notificator/config"
+	"github.com/kirychukyurii/notificator/model"
+)
+
+// Data is the data passed to the webhook body template.
+type Data struct {
+	Notification *model.Notification
+	Status       model.Status
+	Technical    *config.Technical
+	Alerts       []*model.Alert
+
+	// Channel is the channel of the first alert in group.
+	Channel string
+}
+
+var templateFuncs = template.FuncMap{
+	"toUpper":   strings.ToUpper,
+	"toLower":   strings.ToLower,
+	"trimSpace": strings.TrimSpace,
+	"join":      strings.Join,
+	"replace":   func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"truncate": func(n int, s string) string {
+		if r := []rune(s); len(r) > n {
+			return string(r[:n])
+		}
+
+		return s
+	},
+	"default": func(def, v string) string {
+		if v == "" {
+			return def
+		}
+
+		return v
+	},
+
+	// toJSON encodes value as JSON, so strings are quoted and escaped and
+	// may be safely embedded into JSON bodies.
+	"toJSON": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		return string(b), nil
+	},
+	"now": time.Now,
+	"formatTime": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+func executeTemplate(tmpl *template.Template, n *model.Notification, status model.Status, technical *config.Technical, alerts ...*model.Alert) ([]byte, error) {
+	data := &Data{
+		Notification: n,
+		Status:       status,
+		Technical:    technical,
+		Alerts:       alerts,
+	}
+
+	if len(alerts) > 0 {
+		data.Channel = alerts[0].Channel
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
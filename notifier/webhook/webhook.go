@@ -17,14 +17,17 @@ import (
 	"github.com/kirychukyurii/notificator/config/notifiers"
 )
 
+const defaultRequestTimeout = 30 * time.Second
+
 type Options struct {
 	URL                *url.URL
 	InsecureSkipVerify bool
 
-	RetryNum         int
-	RetryTimeout     time.Duration
 	RetryStatusCodes []string
 
+	// ContentType of the request payload, defaults to application/json.
+	ContentType string
+
 	Authorization *notifiers.Authorization
 }
 
@@ -33,7 +36,7 @@ type Client struct {
 	connection *fasthttp.Client
 }
 
-func New(options *Options) (*Client, error) {
+func NewClient(options *Options) (*Client, error) {
 	cli := fasthttp.Client{
 		TLSConfig: &tls.Config{
 			InsecureSkipVerify: options.InsecureSkipVerify,
@@ -46,45 +49,19 @@ func New(options *Options) (*Client, error) {
 	}, nil
 }
 
-func (c *Client) Request(ctx context.Context, requestMethod, requestPath string, requestPayload any, responseStruct any) error {
-	var (
-		err         error
-		shouldRetry bool
-	)
-
+// Request sends the payload and decodes the response into responseStruct. It
+// returns an error if the request is unsuccessful and a flag whether the error
+// is recoverable, e.g. the receiver is temporarily unavailable.
+func (c *Client) Request(ctx context.Context, requestMethod, requestPath string, requestPayload any, responseStruct any) (bool, error) {
 	retryStatusCodes := c.options.RetryStatusCodes
 	if len(retryStatusCodes) == 0 {
 		retryStatusCodes = []string{"429", "5xx"}
 	}
 
-	for n := 0; n <= c.options.RetryNum; n++ {
-
-		// wait a bit if that's not the first request
-		if n != 0 {
-			if c.options.RetryTimeout == 0 {
-				c.options.RetryTimeout = time.Second * 5
-			}
-
-			time.Sleep(time.Second * c.options.RetryTimeout)
-		}
-
-		// If err is not nil, retry again
-		// That's either caused by client policy, or failure to speak HTTP (such as network connectivity problem). A
-		// non-2xx status code doesn't cause an error.
-		shouldRetry, err = c.newRequest(retryStatusCodes, requestMethod, requestPath, requestPayload, responseStruct)
-		if !shouldRetry {
-			break
-		}
-	}
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return c.newRequest(ctx, retryStatusCodes, requestMethod, requestPath, requestPayload, responseStruct)
 }
 
-func (c *Client) newRequest(retryStatusCodes []string, requestMethod, requestPath string, requestPayload any, responseStruct any) (bool, error) {
+func (c *Client) newRequest(ctx context.Context, retryStatusCodes []string, requestMethod, requestPath string, requestPayload any, responseStruct any) (bool, error) {
 	var (
 		err  error
 		body []byte
@@ -93,7 +70,7 @@ func (c *Client) newRequest(retryStatusCodes []string, requestMethod, requestPat
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
-	u := c.options.URL
+	u := *c.options.URL
 	u.Path = path.Join(u.Path, requestPath)
 	req.SetRequestURI(u.String())
 	req.Header.SetMethod(requestMethod)
@@ -102,20 +79,35 @@ func (c *Client) newRequest(retryStatusCodes []string, requestMethod, requestPat
 	if requestPayload != nil {
 		payload, ok := requestPayload.([]byte)
 		if ok {
-			req.Header.SetContentType("application/json")
+			contentType := c.options.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			req.Header.SetContentType(contentType)
 			req.SetBody(payload)
 		}
 	}
 
 	if c.options.Authorization != nil {
-		req.Header.Add(c.options.Authorization.Header, c.options.Authorization.Value)
+		header := c.options.Authorization.Header
+		if header == "" {
+			header = "Authorization"
+		}
+
+		req.Header.Add(header, c.options.Authorization.Value)
 	}
 
 	resp := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(resp)
 
-	if err = c.connection.Do(req, resp); err != nil {
-		return false, fmt.Errorf("client %s get failed: %v", req.RequestURI(), err)
+	timeout := defaultRequestTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	if err = c.connection.DoTimeout(req, resp, timeout); err != nil {
+		return true, fmt.Errorf("client %s get failed: %v", req.RequestURI(), err)
 	}
 
 	shouldRetry, err := matchRetryCode(resp.StatusCode(), retryStatusCodes)
@@ -123,37 +115,38 @@ func (c *Client) newRequest(retryStatusCodes []string, requestMethod, requestPat
 		return false, err
 	}
 
-	if !shouldRetry {
+	if shouldRetry {
+		return true, fmt.Errorf("client %s got retryable status code %d", req.RequestURI(), resp.StatusCode())
+	}
 
-		// do we need to decompress the response?
-		contentEncoding := resp.Header.Peek("Content-Encoding")
-		if bytes.EqualFold(contentEncoding, []byte("gzip")) {
-			body, err = resp.BodyGunzip()
-			if err != nil {
-				return false, fmt.Errorf("decompress the response: %v", err)
-			}
-		} else {
-			body = resp.Body()
+	// do we need to decompress the response?
+	contentEncoding := resp.Header.Peek("Content-Encoding")
+	if bytes.EqualFold(contentEncoding, []byte("gzip")) {
+		body, err = resp.BodyGunzip()
+		if err != nil {
+			return false, fmt.Errorf("decompress the response: %v", err)
 		}
+	} else {
+		body = resp.Body()
+	}
 
-		switch {
-		case resp.StatusCode() == http.StatusNotFound:
-			return false, fmt.Errorf("%v, body: %s", "not found", string(body))
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		return false, fmt.Errorf("%v, body: %s", "not found", string(body))
 
-		case resp.StatusCode() >= 400:
-			return false, fmt.Errorf("expected status code %d but got %d, response: %s", fasthttp.StatusOK, resp.StatusCode(), string(body))
-		}
+	case resp.StatusCode() >= 400:
+		return false, fmt.Errorf("expected status code %d but got %d, response: %s", fasthttp.StatusOK, resp.StatusCode(), string(body))
+	}
 
-		if responseStruct == nil {
-			return false, nil
-		}
+	if responseStruct == nil {
+		return false, nil
+	}
 
-		if err = json.Unmarshal(body, &responseStruct); err != nil {
-			return false, fmt.Errorf("unmarshal json (%s): %v", body, err)
-		}
+	if err = json.Unmarshal(body, &responseStruct); err != nil {
+		return false, fmt.Errorf("unmarshal json (%s): %v", body, err)
 	}
 
-	return shouldRetry, nil
+	return false, nil
 }
 
 // matchRetryCode checks if the status code matches any of the configured retry status codes.
@@ -161,6 +154,11 @@ func matchRetryCode(gottenCode int, retryCodes []string) (bool, error) {
 	gottenCodeStr := strconv.Itoa(gottenCode)
 
 	for _, retryCode := range retryCodes {
+		// Codes are validated by config, the check keeps patterns of other length from panicking.
+		if len(retryCode) != len(gottenCodeStr) {
+			continue
+		}
+
 		matched := true
 		for i := range retryCode {
 			c := retryCode[i]
@@ -0,0 +1,31 @@
+package webhook
+
+import "testing"
+
+func TestMatchRetryCode(t *testing.T) {
+	tests := []struct {
+		code  int
+		retry []string
+		want  bool
+	}{
+		{code: 429, retry: []string{"429", "5xx"}, want: true},
+		{code: 503, retry: []string{"429", "5xx"}, want: true},
+		{code: 404, retry: []string{"429", "5xx"}, want: false},
+		{code: 502, retry: []string{"50x"}, want: true},
+		{code: 510, retry: []string{"50x"}, want: false},
+
+		// Patterns of other length never match instead of panicking.
+		{code: 500, retry: []string{"5xxx", "5"}, want: false},
+	}
+
+	for _, tt := range tests {
+		got, err := matchRetryCode(tt.code, tt.retry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.want {
+			t.Errorf("matchRetryCode(%d, %v) = %v, want %v", tt.code, tt.retry, got, tt.want)
+		}
+	}
+}
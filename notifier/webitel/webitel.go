@@ -3,9 +3,12 @@ package webitel
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-openapi/strfmt"
@@ -27,6 +30,23 @@ type Webitel struct {
 	cfg  *notifiers.WebitelConfig
 	log  *wlog.Logger
 	cli  *client.WebitelAPI
+
+	// members holds created members by their IDs and fingerprints holds IDs of
+	// members by fingerprints of their alerts, so members not dialed yet can be
+	// removed when all their alerts are resolved.
+	mu           sync.Mutex
+	members      map[string]*member
+	fingerprints map[string][]string
+}
+
+// memberTTL is how long created members are tracked, members of alerts which
+// are never resolved are dialed or expired by then.
+const memberTTL = 24 * time.Hour
+
+// member tracks fingerprints of firing alerts the member was created for.
+type member struct {
+	firing    map[string]bool
+	createdAt time.Time
 }
 
 func New(name string, cfg *notifiers.WebitelConfig, log *wlog.Logger) (*Webitel, error) {
@@ -36,14 +56,14 @@ func New(name string, cfg *notifiers.WebitelConfig, log *wlog.Logger) (*Webitel,
 	}
 
 	transport := &client.TransportConfig{
-		Host:             u.Host,
-		BasePath:         u.Path,
-		Schemes:          []string{u.Scheme},
-		APIKey:           cfg.Authorization.Value,
-		TLSConfig:        &tls.Config{},
-		NumRetries:       3,
-		RetryTimeout:     2 * time.Second,
-		RetryStatusCodes: []string{"420", "5xx"},
+		Host:      u.Host,
+		BasePath:  u.Path,
+		Schemes:   []string{u.Scheme},
+		APIKey:    cfg.Authorization.Value,
+		TLSConfig: &tls.Config{},
+
+		// Queue retries recoverable errors with backoff, see recoverable.
+		NumRetries: 0,
 	}
 
 	cli := client.NewHTTPClientWithConfig(strfmt.Default, transport)
@@ -56,10 +76,12 @@ func New(name string, cfg *notifiers.WebitelConfig, log *wlog.Logger) (*Webitel,
 	}
 
 	return &Webitel{
-		name: name,
-		cfg:  cfg,
-		log:  log,
-		cli:  cli,
+		name:         name,
+		cfg:          cfg,
+		log:          log,
+		cli:          cli,
+		members:      make(map[string]*member),
+		fingerprints: make(map[string][]string),
 	}, nil
 }
 
@@ -69,10 +91,22 @@ func (w *Webitel) Notify(ctx context.Context, technical *config.Technical, alert
 
 	variables := make(map[string]string, len(alert))
 	variables["channel"] = alert[0].Channel
+	if s := model.HighestSeverity(alert...); s != "" {
+		variables["severity"] = string(s)
+	}
+
 	for i, a := range alert {
 		variables[fmt.Sprintf("alert-%d", i)] = a.String()
 	}
 
+	// Flow may report the digit pressed by technical to the acknowledgement URL.
+	if n, ok := model.NotificationFromContext(ctx); ok {
+		variables["notification_id"] = n.ID
+		if n.AckURL != "" {
+			variables["ack_url"] = n.AckURL
+		}
+	}
+
 	opts.Body = &models.EngineCreateMemberRequest{
 		Name: fmt.Sprintf("%s: %s", technical.Name, uuid.Must(uuid.NewRandom()).String()),
 		Communications: []*models.EngineMemberCommunicationCreateRequest{
@@ -86,15 +120,118 @@ func (w *Webitel) Notify(ctx context.Context, technical *config.Technical, alert
 		Variables: variables,
 	}
 
-	if _, err := w.cli.MemberService.CreateMemberWithParams(opts); err != nil {
-		return false, err
+	resp, err := w.cli.MemberService.CreateMemberWithParams(opts)
+	if err != nil {
+		return ctx.Err() == nil && recoverable(err), err
 	}
 
 	w.log.Info("create member at Webitel, wait for a call", wlog.Any("member", opts.Body))
+	if resp.Payload != nil && resp.Payload.ID != "" {
+		w.track(resp.Payload.ID, alert...)
+	}
 
 	return false, nil
 }
 
+// recoverable reports whether the failed request may succeed when retried:
+// Webitel was not reached, is overloaded or failed to handle the request.
+func recoverable(err error) bool {
+	var resp interface{ Code() int }
+	if !errors.As(err, &resp) {
+		return true
+	}
+
+	code := resp.Code()
+
+	return code == 420 || code >= 500
+}
+
+// track remembers the member created for the alerts and forgets members older than TTL.
+func (w *Webitel) track(id string, alert ...*model.Alert) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for mid, m := range w.members {
+		if now.Sub(m.createdAt) >= memberTTL {
+			w.forget(mid)
+		}
+	}
+
+	m := &member{firing: make(map[string]bool, len(alert)), createdAt: now}
+	for _, a := range alert {
+		if !m.firing[a.Fingerprint] {
+			m.firing[a.Fingerprint] = true
+			w.fingerprints[a.Fingerprint] = append(w.fingerprints[a.Fingerprint], id)
+		}
+	}
+
+	w.members[id] = m
+}
+
+// forget stops tracking the member. Caller must hold mu.
+func (w *Webitel) forget(id string) {
+	m, ok := w.members[id]
+	if !ok {
+		return
+	}
+
+	for fp := range m.firing {
+		ids := slices.DeleteFunc(w.fingerprints[fp], func(mid string) bool { return mid == id })
+		if len(ids) == 0 {
+			delete(w.fingerprints, fp)
+		} else {
+			w.fingerprints[fp] = ids
+		}
+	}
+
+	delete(w.members, id)
+}
+
+// Resolve removes members not dialed yet once all alerts they were created for are resolved.
+func (w *Webitel) Resolve(ctx context.Context, technical *config.Technical, alert ...*model.Alert) error {
+	ids := make(map[string]struct{})
+	w.mu.Lock()
+	for _, a := range alert {
+		for _, id := range w.fingerprints[a.Fingerprint] {
+			m := w.members[id]
+			delete(m.firing, a.Fingerprint)
+			if len(m.firing) == 0 {
+				ids[id] = struct{}{}
+			}
+		}
+
+		delete(w.fingerprints, a.Fingerprint)
+	}
+
+	for id := range ids {
+		delete(w.members, id)
+	}
+	w.mu.Unlock()
+
+	queueID := strconv.Itoa(w.cfg.QueueID)
+	for id := range ids {
+		member, err := w.cli.MemberService.ReadMember(member_service.NewReadMemberParamsWithContext(ctx).WithQueueID(queueID).WithID(id))
+		if err != nil {
+			return fmt.Errorf("read member %s: %w", id, err)
+		}
+
+		if m := member.Payload; m == nil || m.Attempts > 0 || m.Reserved || m.StopCause != "" {
+			w.log.Debug("member is already dialed, skip", wlog.String("member", id))
+
+			continue
+		}
+
+		if _, err := w.cli.MemberService.DeleteMember(member_service.NewDeleteMemberParamsWithContext(ctx).WithQueueID(queueID).WithID(id)); err != nil {
+			return fmt.Errorf("delete member %s: %w", id, err)
+		}
+
+		w.log.Info("alerts resolved, member removed before call", wlog.String("member", id))
+	}
+
+	return nil
+}
+
 func (w *Webitel) String() string {
 	return w.name
 }
@@ -0,0 +1,31 @@
+package webitel
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/webitel/webitel-openapi-client-go/client/member_service"
+)
+
+func TestRecoverable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "transport", err: &url.Error{Op: "Post", URL: "https://webitel", Err: fmt.Errorf("connection refused")}, want: true},
+		{name: "enhance your calm", err: member_service.NewCreateMemberDefault(420), want: true},
+		{name: "server error", err: member_service.NewCreateMemberDefault(503), want: true},
+		{name: "bad request", err: member_service.NewCreateMemberDefault(400), want: false},
+		{name: "not found", err: member_service.NewCreateMemberDefault(404), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recoverable(tt.err); got != tt.want {
+				t.Errorf("recoverable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kirychukyurii/notificator/config"
+)
+
+const (
+	timeLayout = "2006-01-02T15:04"
+	dayLayout  = "15:04"
+)
+
+// Schedule resolves the technical on duty from configured rotations.
+type Schedule struct {
+	loc       *time.Location
+	layers    []*layer
+	overrides []*override
+}
+
+type layer struct {
+	name       string
+	start      time.Time
+	days       int
+	length     time.Duration
+	technicals []*config.Technical
+
+	// from and to are offsets from midnight, window is disabled when both are zero.
+	from, to time.Duration
+}
+
+type override struct {
+	technical  *config.Technical
+	start, end time.Time
+}
+
+// New parses rotations of the configuration in the given location.
+func New(cfg *config.Config, loc *time.Location) (*Schedule, error) {
+	s := &Schedule{loc: loc}
+	if cfg.Schedule == nil {
+		return s, nil
+	}
+
+	for i, l := range cfg.Schedule.Layers {
+		parsed, err := newLayer(cfg, l, loc)
+		if err != nil {
+			return nil, fmt.Errorf("schedule layer %d (%s): %v", i, l.Name, err)
+		}
+
+		s.layers = append(s.layers, parsed)
+	}
+
+	for i, o := range cfg.Schedule.Overrides {
+		t, ok := cfg.Technical(o.Technical)
+		if !ok {
+			return nil, fmt.Errorf("schedule override %d: technical %q not found", i, o.Technical)
+		}
+
+		start, err := time.ParseInLocation(timeLayout, o.Start, loc)
+		if err != nil {
+			return nil, fmt.Errorf("schedule override %d: start: %v", i, err)
+		}
+
+		end, err := time.ParseInLocation(timeLayout, o.End, loc)
+		if err != nil {
+			return nil, fmt.Errorf("schedule override %d: end: %v", i, err)
+		}
+
+		if !end.After(start) {
+			return nil, fmt.Errorf("schedule override %d: end must be after start", i)
+		}
+
+		s.overrides = append(s.overrides, &override{technical: t, start: start, end: end})
+	}
+
+	return s, nil
+}
+
+func newLayer(cfg *config.Config, l *config.RotationLayer, loc *time.Location) (*layer, error) {
+	if len(l.Technicals) == 0 {
+		return nil, fmt.Errorf("no technicals in rotation")
+	}
+
+	start, err := time.ParseInLocation(timeLayout, l.Start, loc)
+	if err != nil {
+		return nil, fmt.Errorf("start: %v", err)
+	}
+
+	parsed := &layer{name: l.Name, start: start}
+	switch l.Rotation {
+	case "daily", "":
+		parsed.days = 1
+	case "weekly":
+		parsed.days = 7
+	default:
+		if parsed.length, err = time.ParseDuration(l.Rotation); err != nil {
+			return nil, fmt.Errorf("rotation: %v", err)
+		}
+
+		if parsed.length <= 0 {
+			return nil, fmt.Errorf("rotation must be positive")
+		}
+	}
+
+	for _, name := range l.Technicals {
+		t, ok := cfg.Technical(name)
+		if !ok {
+			return nil, fmt.Errorf("technical %q not found", name)
+		}
+
+		parsed.technicals = append(parsed.technicals, t)
+	}
+
+	if l.From != "" || l.To != "" {
+		if parsed.from, err = parseTimeOfDay(l.From); err != nil {
+			return nil, fmt.Errorf("from: %v", err)
+		}
+
+		if parsed.to, err = parseTimeOfDay(l.To); err != nil {
+			return nil, fmt.Errorf("to: %v", err)
+		}
+
+		if parsed.from == parsed.to {
+			return nil, fmt.Errorf("window from and to are equal")
+		}
+	}
+
+	return parsed, nil
+}
+
+// Enabled reports whether any rotation is configured.
+func (s *Schedule) Enabled() bool {
+	return len(s.layers) > 0 || len(s.overrides) > 0
+}
+
+// OnCall returns the technical on duty at the given time: the override active at
+// that time or the current technical of the last layer whose window includes it.
+func (s *Schedule) OnCall(t time.Time) (*config.Technical, bool) {
+	t = t.In(s.loc)
+	for i := len(s.overrides) - 1; i >= 0; i-- {
+		o := s.overrides[i]
+		if !t.Before(o.start) && t.Before(o.end) {
+			return o.technical, true
+		}
+	}
+
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		if technical, ok := s.layers[i].onCall(t); ok {
+			return technical, true
+		}
+	}
+
+	return nil, false
+}
+
+func (l *layer) onCall(t time.Time) (*config.Technical, bool) {
+	if t.Before(l.start) || !l.active(t) {
+		return nil, false
+	}
+
+	shift := l.shift(t)
+
+	return l.technicals[shift%len(l.technicals)], true
+}
+
+// shift returns the number of handoffs between the layer start and t. Daily and
+// weekly rotations hand off at the same wall clock time regardless of DST changes.
+func (l *layer) shift(t time.Time) int {
+	if l.length > 0 {
+		return int(t.Sub(l.start) / l.length)
+	}
+
+	days := int(t.Sub(l.start).Hours() / 24)
+	for days > 0 && l.start.AddDate(0, 0, days).After(t) {
+		days--
+	}
+
+	for !l.start.AddDate(0, 0, days+1).After(t) {
+		days++
+	}
+
+	return days / l.days
+}
+
+// active reports whether t is in the time of day window of the layer, the window
+// may wrap over midnight.
+func (l *layer) active(t time.Time) bool {
+	if l.from == 0 && l.to == 0 {
+		return true
+	}
+
+	h, m, sec := t.Clock()
+	now := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second
+	if l.from < l.to {
+		return now >= l.from && now < l.to
+	}
+
+	return now >= l.from || now < l.to
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse(dayLayout, s)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
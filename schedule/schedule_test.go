@@ -0,0 +1,304 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/kirychukyurii/notificator/config"
+)
+
+// check is the technical expected on duty at the time, empty if nobody is.
+type check struct {
+	at   string
+	want string
+}
+
+func newTestConfig(s *config.Schedule) *config.Config {
+	cfg := &config.Config{Schedule: s}
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		cfg.Technicals = append(cfg.Technicals, &config.Technical{Name: name})
+	}
+
+	return cfg
+}
+
+func testSchedule(t *testing.T, loc *time.Location, s *config.Schedule, checks []check) {
+	t.Helper()
+
+	sched, err := New(newTestConfig(s), loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range checks {
+		at, err := time.ParseInLocation(timeLayout, c.at, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got string
+		if technical, ok := sched.OnCall(at); ok {
+			got = technical.Name
+		}
+
+		if got != c.want {
+			t.Errorf("on call at %s: %q, want %q", c.at, got, c.want)
+		}
+	}
+}
+
+func TestRotationBoundaries(t *testing.T) {
+	tests := []struct {
+		name   string
+		layer  *config.RotationLayer
+		checks []check
+	}{
+		{
+			name:  "daily",
+			layer: &config.RotationLayer{Start: "2024-03-01T09:00", Rotation: "daily", Technicals: []string{"alice", "bob", "carol"}},
+			checks: []check{
+				{at: "2024-03-01T08:59"},
+				{at: "2024-03-01T09:00", want: "alice"},
+				{at: "2024-03-02T08:59", want: "alice"},
+				{at: "2024-03-02T09:00", want: "bob"},
+				{at: "2024-03-03T09:00", want: "carol"},
+				{at: "2024-03-04T09:00", want: "alice"},
+			},
+		},
+		{
+			name:  "weekly",
+			layer: &config.RotationLayer{Start: "2024-03-04T09:00", Rotation: "weekly", Technicals: []string{"alice", "bob"}},
+			checks: []check{
+				{at: "2024-03-04T09:00", want: "alice"},
+				{at: "2024-03-11T08:59", want: "alice"},
+				{at: "2024-03-11T09:00", want: "bob"},
+				{at: "2024-03-18T09:00", want: "alice"},
+			},
+		},
+		{
+			name:  "duration",
+			layer: &config.RotationLayer{Start: "2024-03-01T09:00", Rotation: "12h", Technicals: []string{"alice", "bob"}},
+			checks: []check{
+				{at: "2024-03-01T20:59", want: "alice"},
+				{at: "2024-03-01T21:00", want: "bob"},
+				{at: "2024-03-02T09:00", want: "alice"},
+			},
+		},
+		{
+			name:  "single technical",
+			layer: &config.RotationLayer{Start: "2024-03-01T09:00", Technicals: []string{"alice"}},
+			checks: []check{
+				{at: "2024-03-01T09:00", want: "alice"},
+				{at: "2025-03-01T09:00", want: "alice"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testSchedule(t, time.UTC, &config.Schedule{Layers: []*config.RotationLayer{tt.layer}}, tt.checks)
+		})
+	}
+}
+
+func TestRotationDST(t *testing.T) {
+	// Clocks go forward on 2024-03-31 and back on 2024-10-27 at 04:00 local time.
+	loc, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		layer  *config.RotationLayer
+		checks []check
+	}{
+		{
+			// The day is 23 hours long, handoff is still at 09:00.
+			name:  "daily on spring forward",
+			layer: &config.RotationLayer{Start: "2024-03-30T09:00", Rotation: "daily", Technicals: []string{"alice", "bob"}},
+			checks: []check{
+				{at: "2024-03-31T08:59", want: "alice"},
+				{at: "2024-03-31T09:00", want: "bob"},
+				{at: "2024-04-01T09:00", want: "alice"},
+			},
+		},
+		{
+			// The day is 25 hours long, handoff is still at 09:00.
+			name:  "daily on fall back",
+			layer: &config.RotationLayer{Start: "2024-10-26T09:00", Rotation: "daily", Technicals: []string{"alice", "bob"}},
+			checks: []check{
+				{at: "2024-10-27T08:59", want: "alice"},
+				{at: "2024-10-27T09:00", want: "bob"},
+				{at: "2024-10-28T09:00", want: "alice"},
+			},
+		},
+		{
+			name:  "weekly over spring forward",
+			layer: &config.RotationLayer{Start: "2024-03-25T09:00", Rotation: "weekly", Technicals: []string{"alice", "bob"}},
+			checks: []check{
+				{at: "2024-04-01T08:59", want: "alice"},
+				{at: "2024-04-01T09:00", want: "bob"},
+			},
+		},
+		{
+			// Shifts of fixed duration are measured in elapsed time.
+			name:  "duration on spring forward",
+			layer: &config.RotationLayer{Start: "2024-03-30T21:00", Rotation: "12h", Technicals: []string{"alice", "bob"}},
+			checks: []check{
+				{at: "2024-03-31T09:00", want: "alice"},
+				{at: "2024-03-31T09:59", want: "alice"},
+				{at: "2024-03-31T10:00", want: "bob"},
+			},
+		},
+		{
+			name:  "duration on fall back",
+			layer: &config.RotationLayer{Start: "2024-10-26T21:00", Rotation: "12h", Technicals: []string{"alice", "bob"}},
+			checks: []check{
+				{at: "2024-10-27T07:59", want: "alice"},
+				{at: "2024-10-27T08:00", want: "bob"},
+			},
+		},
+		{
+			// Window is the wall clock time of the day.
+			name: "night window on spring forward",
+			layer: &config.RotationLayer{
+				Start: "2024-03-30T21:00", Rotation: "daily", Technicals: []string{"alice", "bob"},
+				From: "21:00", To: "09:00",
+			},
+			checks: []check{
+				{at: "2024-03-31T05:00", want: "alice"},
+				{at: "2024-03-31T08:59", want: "alice"},
+				{at: "2024-03-31T09:00"},
+				{at: "2024-03-31T21:00", want: "bob"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testSchedule(t, loc, &config.Schedule{Layers: []*config.RotationLayer{tt.layer}}, tt.checks)
+		})
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	s := &config.Schedule{
+		Layers: []*config.RotationLayer{
+			{Start: "2024-03-01T09:00", Rotation: "daily", Technicals: []string{"alice", "bob"}},
+		},
+		Overrides: []*config.ScheduleOverride{
+			{Technical: "carol", Start: "2024-03-02T12:00", End: "2024-03-02T18:00"},
+
+			// Later overrides take precedence over earlier ones.
+			{Technical: "dave", Start: "2024-03-02T15:00", End: "2024-03-02T20:00"},
+
+			// Overrides apply before the first handoff of layers.
+			{Technical: "carol", Start: "2024-02-29T00:00", End: "2024-03-01T00:00"},
+		},
+	}
+
+	testSchedule(t, time.UTC, s, []check{
+		{at: "2024-02-29T12:00", want: "carol"},
+		{at: "2024-03-01T00:00"},
+		{at: "2024-03-02T11:59", want: "bob"},
+		{at: "2024-03-02T12:00", want: "carol"},
+		{at: "2024-03-02T14:59", want: "carol"},
+		{at: "2024-03-02T15:00", want: "dave"},
+		{at: "2024-03-02T17:59", want: "dave"},
+		{at: "2024-03-02T19:59", want: "dave"},
+		{at: "2024-03-02T20:00", want: "bob"},
+	})
+}
+
+func TestLayerGaps(t *testing.T) {
+	s := &config.Schedule{
+		Layers: []*config.RotationLayer{
+			{Name: "night", Start: "2024-03-01T21:00", Rotation: "daily", Technicals: []string{"alice", "bob"}, From: "21:00", To: "09:00"},
+			{Name: "day", Start: "2024-03-01T09:00", Rotation: "daily", Technicals: []string{"carol", "dave"}, From: "09:00", To: "18:00"},
+		},
+	}
+
+	testSchedule(t, time.UTC, s, []check{
+		{at: "2024-03-01T08:59"},
+		{at: "2024-03-01T09:00", want: "carol"},
+		{at: "2024-03-01T17:59", want: "carol"},
+
+		// Nobody covers the evening.
+		{at: "2024-03-01T18:00"},
+		{at: "2024-03-01T20:59"},
+
+		// Night window wraps over midnight.
+		{at: "2024-03-01T21:00", want: "alice"},
+		{at: "2024-03-02T00:00", want: "alice"},
+		{at: "2024-03-02T08:59", want: "alice"},
+		{at: "2024-03-02T09:00", want: "dave"},
+		{at: "2024-03-02T21:00", want: "bob"},
+	})
+}
+
+func TestLayerPrecedence(t *testing.T) {
+	s := &config.Schedule{
+		Layers: []*config.RotationLayer{
+			{Name: "base", Start: "2024-03-01T00:00", Rotation: "weekly", Technicals: []string{"alice"}},
+			{Name: "business hours", Start: "2024-03-01T09:00", Rotation: "daily", Technicals: []string{"bob", "carol"}, From: "09:00", To: "18:00"},
+		},
+	}
+
+	testSchedule(t, time.UTC, s, []check{
+		{at: "2024-03-01T08:59", want: "alice"},
+		{at: "2024-03-01T09:00", want: "bob"},
+		{at: "2024-03-01T18:00", want: "alice"},
+		{at: "2024-03-02T10:00", want: "carol"},
+	})
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *config.Schedule
+	}{
+		{name: "no technicals", schedule: &config.Schedule{Layers: []*config.RotationLayer{
+			{Start: "2024-03-01T09:00"},
+		}}},
+		{name: "unknown technical", schedule: &config.Schedule{Layers: []*config.RotationLayer{
+			{Start: "2024-03-01T09:00", Technicals: []string{"eve"}},
+		}}},
+		{name: "invalid start", schedule: &config.Schedule{Layers: []*config.RotationLayer{
+			{Start: "2024-03-01 09:00", Technicals: []string{"alice"}},
+		}}},
+		{name: "invalid rotation", schedule: &config.Schedule{Layers: []*config.RotationLayer{
+			{Start: "2024-03-01T09:00", Rotation: "monthly", Technicals: []string{"alice"}},
+		}}},
+		{name: "negative rotation", schedule: &config.Schedule{Layers: []*config.RotationLayer{
+			{Start: "2024-03-01T09:00", Rotation: "-12h", Technicals: []string{"alice"}},
+		}}},
+		{name: "empty window", schedule: &config.Schedule{Layers: []*config.RotationLayer{
+			{Start: "2024-03-01T09:00", Technicals: []string{"alice"}, From: "09:00", To: "09:00"},
+		}}},
+		{name: "override of unknown technical", schedule: &config.Schedule{Overrides: []*config.ScheduleOverride{
+			{Technical: "eve", Start: "2024-03-01T09:00", End: "2024-03-02T09:00"},
+		}}},
+		{name: "override ends before start", schedule: &config.Schedule{Overrides: []*config.ScheduleOverride{
+			{Technical: "alice", Start: "2024-03-02T09:00", End: "2024-03-01T09:00"},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(newTestConfig(tt.schedule), time.UTC); err == nil {
+				t.Fatal("invalid schedule parsed")
+			}
+		})
+	}
+
+	s, err := New(newTestConfig(nil), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Enabled() {
+		t.Error("schedule without rotations is enabled")
+	}
+}
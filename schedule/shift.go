@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kirychukyurii/notificator/config"
+)
+
+const shiftFile = "shift.json"
+
+// Shift is the current listening window and the technical on duty, persisted
+// to restore it when the app restarts inside the window.
+type Shift struct {
+	Technical *config.Technical `json:"technical"`
+	StartedAt time.Time         `json:"started_at"`
+	EndsAt    time.Time         `json:"ends_at"`
+
+	// Manual is set when the technical was chosen in the manager bot.
+	Manual bool `json:"manual"`
+}
+
+// Active reports whether the shift has not ended at the given time.
+func (s *Shift) Active(now time.Time) bool {
+	return s.Technical != nil && now.Before(s.EndsAt)
+}
+
+// SaveShift writes the shift to the sessions directory.
+func SaveShift(sessionDir string, shift *Shift) error {
+	data, err := json.MarshalIndent(shift, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(sessionDir, shiftFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// LoadShift reads the shift from the sessions directory, it returns nil if
+// there is no saved shift.
+func LoadShift(sessionDir string) (*Shift, error) {
+	data, err := os.ReadFile(filepath.Join(sessionDir, shiftFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var shift Shift
+	if err := json.Unmarshal(data, &shift); err != nil {
+		return nil, err
+	}
+
+	return &shift, nil
+}
+
+// RemoveShift removes the saved shift when the listening window ends.
+func RemoveShift(sessionDir string) error {
+	if err := os.Remove(filepath.Join(sessionDir, shiftFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/webitel/wlog"
 
@@ -34,7 +35,15 @@ func New(log *wlog.Logger, cfg *config.HttpServer) (*Server, error) {
 	return server, nil
 }
 
+// HandleFunc registers the handler for the pattern under the root path, the
+// pattern may start with the method, e.g. "POST /ack/{id}".
 func (s *Server) HandleFunc(pattern string, handler http.HandlerFunc) {
+	if method, path, ok := strings.Cut(pattern, " "); ok {
+		s.mux.HandleFunc(method+" "+s.cfg.Root+path, handler)
+
+		return
+	}
+
 	s.mux.HandleFunc(s.cfg.Root+pattern, handler)
 }
 
@@ -56,6 +65,11 @@ func (s *Server) Stop(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
 
+// APIToken returns the token required by management API, empty if not protected.
+func (s *Server) APIToken() string {
+	return s.cfg.APIToken
+}
+
 func (s *Server) PublicURL() string {
 	return s.cfg.PublicURL + s.cfg.Root
 }